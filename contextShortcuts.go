@@ -0,0 +1,115 @@
+package loggos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/jsonprinter"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// The below functions carry request scoped fields and loggers in a context.Context.
+// Look at the function comments in the jsonprinter package for details on how fields are applied.
+
+// ContextWithFields returns a copy of ctx that carries fields. Messages sent with SendJSONContext
+// and the Context line shortcuts get the fields added. Fields already in ctx are kept unless
+// fields has the same key.
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	return jsonprinter.ContextWithFields(ctx, fields)
+}
+
+// FieldsFromContext returns the fields stored in ctx with ContextWithFields.
+func FieldsFromContext(ctx context.Context) map[string]interface{} {
+	return jsonprinter.FieldsFromContext(ctx)
+}
+
+// ContextWithJSONLogger returns a copy of ctx that carries a scoped JSON logger, made with
+// Named or With. SendJSONContext sends through it instead of the default JSON logger.
+func ContextWithJSONLogger(ctx context.Context, logger *jsonprinter.Child) context.Context {
+	return jsonprinter.ContextWithChild(ctx, logger)
+}
+
+// JSONLoggerFromContext returns the scoped JSON logger stored in ctx with ContextWithJSONLogger.
+// The bool is false if there is none.
+func JSONLoggerFromContext(ctx context.Context) (*jsonprinter.Child, bool) {
+	return jsonprinter.ChildFromContext(ctx)
+}
+
+// SendJSONContext sends a JSON message with the fields carried by ctx. If ctx carries a scoped
+// JSON logger the message is sent through it, otherwise it goes to the default JSON logger.
+func SendJSONContext(ctx context.Context, msg *jsonmessage.JSONMessage) {
+	if logger, ok := JSONLoggerFromContext(ctx); ok {
+		logger.SendContext(ctx, msg)
+		return
+	}
+	startdefaultJSONLogger()
+	DefaultJSONLogger.SendContextDepth(ctx, 1, msg)
+}
+
+// JSONLoggerAddContextExtractor starts the default JSON logger if not already started then
+// adds the supplied context extractor to the list.
+func JSONLoggerAddContextExtractor(extractor jsonprinter.ContextExtractor) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.AddContextExtractor(extractor)
+}
+
+// The line logger has no fields so the Context shortcuts put the fields carried by ctx at the
+// end of the line as key=value pairs, sorted by key.
+
+func TracefContext(ctx context.Context, format string, vars ...interface{}) {
+	format, vars = contextFormat(ctx, shared.LevelTrace, format, vars)
+	DefaultLineLogger.LogfDepth(1, shared.LevelTrace, format, vars...)
+}
+func DebugfContext(ctx context.Context, format string, vars ...interface{}) {
+	format, vars = contextFormat(ctx, shared.LevelDebug, format, vars)
+	DefaultLineLogger.LogfDepth(1, shared.LevelDebug, format, vars...)
+}
+func InfofContext(ctx context.Context, format string, vars ...interface{}) {
+	format, vars = contextFormat(ctx, shared.LevelInfo, format, vars)
+	DefaultLineLogger.LogfDepth(1, shared.LevelInfo, format, vars...)
+}
+func WarnfContext(ctx context.Context, format string, vars ...interface{}) {
+	format, vars = contextFormat(ctx, shared.LevelWarn, format, vars)
+	DefaultLineLogger.LogfDepth(1, shared.LevelWarn, format, vars...)
+}
+func ErrorfContext(ctx context.Context, format string, vars ...interface{}) {
+	format, vars = contextFormat(ctx, shared.LevelError, format, vars)
+	DefaultLineLogger.LogfDepth(1, shared.LevelError, format, vars...)
+}
+func CritfContext(ctx context.Context, format string, vars ...interface{}) {
+	format, vars = contextFormat(ctx, shared.LevelCrit, format, vars)
+	DefaultLineLogger.LogfDepth(1, shared.LevelCrit, format, vars...)
+}
+
+// contextFormat starts the line logger if needed and adds the fields carried by ctx to the end of
+// the format. The shortcuts call LogfDepth themselves so that the logger finds the right caller.
+func contextFormat(ctx context.Context, level shared.Level, format string, vars []interface{}) (string, []interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	if !DefaultLineLogger.Enabled(level) {
+		// Let the logger count the filtered message.
+		return format, vars
+	}
+	return "%s%s", []interface{}{fmt.Sprintf(format, vars...), fieldSuffix(FieldsFromContext(ctx))}
+}
+
+// fieldSuffix turns fields into " key=value" pairs sorted by key.
+func fieldSuffix(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	b := strings.Builder{}
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, fields[key])
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package loggos
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/silverstagtech/gotracer"
+)
+
+func TestSendJSONContext(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+
+	tracing := gotracer.New()
+	startdefaultJSONLogger()
+	DefaultJSONLogger.OverridePrinter(tracing)
+
+	ctx := ContextWithFields(context.Background(), map[string]interface{}{"request_id": "abc"})
+	SendJSONContext(ctx, JSONInfoln("Test Message - default"))
+
+	scoped := DefaultJSONLogger.Named("http")
+	SendJSONContext(ContextWithJSONLogger(ctx, scoped), JSONInfoln("Test Message - scoped"))
+	<-Flush()
+
+	expected := []string{
+		`"request_id":"abc"`,
+		`"logger":"http"`,
+	}
+	got := tracing.Show()
+	if len(got) != 2 {
+		t.Fatalf("Wanted 2 messages. Got: %v", got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) || !regexp.MustCompile(`"request_id":"abc"`).MatchString(got[i]) {
+			t.Logf("Message %d is missing %s or the context fields. Got: %s", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestInfofContext(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+
+	tracing := gotracer.New()
+	startdefaultLineLogger()
+	DefaultLineLogger.OverridePrinter(tracing)
+
+	ctx := ContextWithFields(context.Background(), map[string]interface{}{"tenant": "acme", "request_id": "abc"})
+	InfofContext(ctx, "Test Message - %s", "InfofContext")
+	DebugfContext(ctx, "Test Message - %s", "DebugfContext")
+	<-Flush()
+
+	got := tracing.Show()
+	if len(got) != 1 {
+		t.Fatalf("Wanted only the info message. Got: %v", got)
+	}
+	if !regexp.MustCompile(`INFO Test Message - InfofContext request_id=abc tenant=acme$`).MatchString(got[0]) {
+		t.Logf("Line message does not end with the context fields. Got: %s", got[0])
+		t.Fail()
+	}
+	if stats := Stats(); stats.DebugFiltered != 1 {
+		t.Logf("Filtered context message was not counted. Stats: %+v", stats)
+		t.Fail()
+	}
+}
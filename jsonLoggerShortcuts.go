@@ -3,6 +3,7 @@ package loggos
 import (
 	"github.com/silverstagtech/loggos/jsonmessage"
 	"github.com/silverstagtech/loggos/jsonprinter"
+	"github.com/silverstagtech/loggos/shared"
 )
 
 // The below functions are all shortcuts that relate to the default logger.
@@ -11,7 +12,26 @@ import (
 // SendJSON is used to send a JSON message to the default JSON logger.
 func SendJSON(msg *jsonmessage.JSONMessage) {
 	startdefaultJSONLogger()
-	DefaultJSONLogger.Send(msg)
+	DefaultJSONLogger.SendDepth(1, msg)
+}
+
+// SendJSONFatal sends a JSON message to the default JSON logger, flushes all the default loggers
+// then exits with status 1. Pair it with JSONFatalln or JSONFatalf.
+func SendJSONFatal(msg *jsonmessage.JSONMessage) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.SendDepth(1, msg)
+	<-Flush()
+	exit(1)
+}
+
+// SendJSONPanic sends a JSON message to the default JSON logger, waits for the default loggers
+// to print it then panics with the message. The loggers keep running in case the panic is
+// recovered. Pair it with JSONPanicln or JSONPanicf.
+func SendJSONPanic(msg *jsonmessage.JSONMessage) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.SendDepth(1, msg)
+	<-Sync()
+	panic(msg.String())
 }
 
 // JSONLoggerEnableDebugLogging Starts the default JSON logger if not already started then
@@ -21,6 +41,13 @@ func JSONLoggerEnableDebugLogging(toggle bool) {
 	DefaultJSONLogger.EnableDebugLogging(toggle)
 }
 
+// JSONLoggerSetLevel starts the default JSON logger if not already started then
+// sets the lowest level of message that it will print.
+func JSONLoggerSetLevel(level shared.Level) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.SetLevel(level)
+}
+
 // JSONLoggerEnablePrettyPrint starts the default JSON logger if not already started then
 // enabled debug logging
 func JSONLoggerEnablePrettyPrint(toggle bool) {
@@ -35,6 +62,20 @@ func JSONLoggerEnableHumanTimestamps(toggle bool) {
 	DefaultJSONLogger.EnableHumanTimestamps(toggle)
 }
 
+// JSONLoggerSetNesting starts the default JSON logger if not already started then
+// sets what happens to nested objects and dotted keys when messages are written.
+func JSONLoggerSetNesting(nesting jsonmessage.Nesting) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.SetNesting(nesting)
+}
+
+// JSONLoggerSetEncoder starts the default JSON logger if not already started then
+// sets how messages are written.
+func JSONLoggerSetEncoder(encoder jsonmessage.Encoder) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.SetEncoder(encoder)
+}
+
 // JSONLoggerAddDecoration starts the default JSON logger if not already started then
 // adds the supplied decoration to the list.
 func JSONLoggerAddDecoration(decoration map[string]interface{}) {
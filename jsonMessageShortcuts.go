@@ -73,3 +73,75 @@ func JSONDebugf(format string, vars ...interface{}) *jsonmessage.JSONMessage {
 	jmsg.Messagef(format, vars...)
 	return jmsg
 }
+
+// JSONTraceln is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONTraceln(msg ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetTrace()
+	jmsg.Message(msg...)
+	return jmsg
+}
+
+// JSONErrorln is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONErrorln(msg ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetError()
+	jmsg.Message(msg...)
+	return jmsg
+}
+
+// JSONFatalln is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONFatalln(msg ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetFatal()
+	jmsg.Message(msg...)
+	return jmsg
+}
+
+// JSONPanicln is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONPanicln(msg ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetPanic()
+	jmsg.Message(msg...)
+	return jmsg
+}
+
+// JSONTracef is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONTracef(format string, vars ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetTrace()
+	jmsg.Messagef(format, vars...)
+	return jmsg
+}
+
+// JSONErrorf is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONErrorf(format string, vars ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetError()
+	jmsg.Messagef(format, vars...)
+	return jmsg
+}
+
+// JSONFatalf is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONFatalf(format string, vars ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetFatal()
+	jmsg.Messagef(format, vars...)
+	return jmsg
+}
+
+// JSONPanicf is a shortcut function that will give you a JSON Message that is populated with time, level
+// and your message ready to be shipped. It can still be decorated with more keys if needed.
+func JSONPanicf(format string, vars ...interface{}) *jsonmessage.JSONMessage {
+	jmsg := jsonmessage.New()
+	jmsg.SetPanic()
+	jmsg.Messagef(format, vars...)
+	return jmsg
+}
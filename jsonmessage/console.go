@@ -0,0 +1,115 @@
+package jsonmessage
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// consoleLevelWidth is the length of the longest level names.
+const consoleLevelWidth = 5
+
+// ConsoleTimeFormat is the format ConsoleEncoder writes timestamps in when they are the default
+// epoch nanosecond stamps.
+var ConsoleTimeFormat = "15:04:05.000"
+
+// ConsoleEncoder writes messages as a single line for people to read in a terminal, the
+// timestamp, the level, the message and then the rest of the keys as key=value pairs like
+// LogfmtEncoder writes them. With Colour set the timestamp is dimmed, the level is coloured
+// and the keys are highlighted.
+type ConsoleEncoder struct {
+	Colour bool
+}
+
+// Encode appends msg to dst as a console line.
+func (e ConsoleEncoder) Encode(dst []byte, msg *JSONMessage) []byte {
+	if stamp, ok := msg.getString(JSONTimeStampKey); ok {
+		dst = e.colour(dst, shared.ColourDim)
+		dst = appendConsoleTime(dst, stamp)
+		dst = e.colour(dst, shared.ColourReset)
+		dst = append(dst, ' ')
+	}
+
+	level, _ := msg.Level()
+	dst = e.colour(dst, shared.LevelColour(level))
+	name := level.String()
+	dst = append(dst, name...)
+	dst = e.colour(dst, shared.ColourReset)
+	// Pad the level so that the messages line up.
+	for n := len(name); n < consoleLevelWidth; n++ {
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, ' ')
+
+	if message, ok := msg.get(JSONMessageKey); ok {
+		if s, ok := message.(string); ok {
+			dst = appendConsoleText(dst, s)
+		} else {
+			dst = appendLogfmtValue(dst, message)
+		}
+	}
+
+	var scratch [32]string
+	for _, key := range msg.encodingKeys(scratch[:0]) {
+		if key == JSONTimeStampKey || key == JSONLevelKey || key == JSONMessageKey {
+			continue
+		}
+		value, _ := msg.get(key)
+		dst = e.appendPair(dst, key, value)
+	}
+	return dst
+}
+
+// appendPair appends a space and key=value to dst, writing nested objects as dotted keys.
+func (e ConsoleEncoder) appendPair(dst []byte, key string, value interface{}) []byte {
+	if object, ok := value.(map[string]interface{}); ok {
+		var scratch [32]string
+		keys := scratch[:0]
+		for k := range object {
+			keys = append(keys, k)
+		}
+		sortKeys(keys)
+		for _, k := range keys {
+			dst = e.appendPair(dst, key+PathSeparator+k, object[k])
+		}
+		return dst
+	}
+
+	dst = append(dst, ' ')
+	dst = e.colour(dst, shared.ColourKey)
+	dst = appendLogfmtKey(dst, key)
+	dst = e.colour(dst, shared.ColourReset)
+	dst = append(dst, '=')
+	return appendLogfmtValue(dst, value)
+}
+
+func (e ConsoleEncoder) colour(dst []byte, code string) []byte {
+	if !e.Colour {
+		return dst
+	}
+	return append(dst, code...)
+}
+
+// appendConsoleTime writes the default epoch nanosecond stamps in ConsoleTimeFormat and any
+// other stamp as it is.
+func appendConsoleTime(dst []byte, stamp string) []byte {
+	nanos, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return appendConsoleText(dst, stamp)
+	}
+	return time.Unix(0, nanos).AppendFormat(dst, ConsoleTimeFormat)
+}
+
+// appendConsoleText appends s as it is so that messages stay easy to read, unless it has
+// anything in it that could break the line or play with the terminal, such as new lines and
+// escape codes, in which case it is quoted.
+func appendConsoleText(dst []byte, s string) []byte {
+	for _, r := range s {
+		if r != ' ' && (r < ' ' || r == utf8.RuneError || !strconv.IsPrint(r)) {
+			return strconv.AppendQuote(dst, s)
+		}
+	}
+	return append(dst, s...)
+}
@@ -0,0 +1,69 @@
+package jsonmessage
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+func TestConsoleEncoder(t *testing.T) {
+	stamp := time.Date(2019, 5, 2, 21, 4, 5, 123000000, time.Local)
+
+	jm := New()
+	jm.Add(JSONTimeStampKey, strconv.FormatInt(stamp.UnixNano(), 10))
+	jm.SetWarn()
+	jm.Message("disk nearly full")
+	jm.AddPath("disk.used", 0.91)
+	jm.Add("host", "web 1")
+
+	want := `^21:04:05.123 WARN  disk nearly full disk.used=0.91 host="web 1"$`
+	if got := string(ConsoleEncoder{}.Encode(nil, jm)); !regexp.MustCompile(want).MatchString(got) {
+		t.Logf("Plain console line does not match %s. Got: %q", want, got)
+		t.Fail()
+	}
+
+	coloured := string(ConsoleEncoder{Colour: true}.Encode(nil, jm))
+	want = regexp.QuoteMeta(shared.ColourDim+"21:04:05.123"+shared.ColourReset+" "+shared.LevelColour(shared.LevelWarn)+"WARN"+shared.ColourReset) +
+		`  disk nearly full ` + regexp.QuoteMeta(shared.ColourKey+"disk.used"+shared.ColourReset) + `=0.91`
+	if !regexp.MustCompile(want).MatchString(coloured) {
+		t.Logf("Coloured console line does not match. Got: %q", coloured)
+		t.Fail()
+	}
+
+	// Stamps that are not the default are written as they are.
+	jm.Add(JSONTimeStampKey, "yesterday")
+	jm.SetLevel(shared.LevelError)
+	if got := string(ConsoleEncoder{}.Encode(nil, jm)); !regexp.MustCompile(`^yesterday ERROR disk`).MatchString(got) {
+		t.Logf("Custom stamp was changed. Got: %q", got)
+		t.Fail()
+	}
+}
+
+func TestConsoleEncoderEscapes(t *testing.T) {
+	jm := New()
+	jm.Add(JSONTimeStampKey, "now\x1b[2J")
+	jm.SetWarn()
+	jm.Message("line one\nFAKE  forged line\x1b[31m")
+	jm.Add("user", "bob\nINFO  hello")
+
+	got := string(ConsoleEncoder{}.Encode(nil, jm))
+	if strings.ContainsAny(got, "\n\x1b") {
+		t.Logf("Control characters were written as they are. Got: %q", got)
+		t.FailNow()
+	}
+	want := `"now\x1b[2J" WARN  "line one\nFAKE  forged line\x1b[31m" user="bob\nINFO  hello"`
+	if got != want {
+		t.Logf("Console line was not escaped like logfmt.\nWant: %s\nGot:  %s", want, got)
+		t.Fail()
+	}
+
+	jm.Message("plain words stay as they are")
+	if got := string(ConsoleEncoder{}.Encode(nil, jm)); !strings.Contains(got, " plain words stay as they are ") {
+		t.Logf("Printable message was quoted. Got: %q", got)
+		t.Fail()
+	}
+}
@@ -0,0 +1,227 @@
+package jsonmessage
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// The encoder writes messages the same way encoding/json would, HTML characters escaped and
+// keys sorted unless the message uses InsertionOrder, but writes typed fields and the common
+// value types directly instead of going through reflection.
+
+const hex = "0123456789abcdef"
+
+// appendJSON appends the message as a JSON object to b.
+func (j *JSONMessage) appendJSON(b []byte) ([]byte, error) {
+	// Most messages have a handful of keys, keeping them in an array on the stack saves an
+	// allocation for every message.
+	var scratch [32]string
+	keys := j.encodingKeys(scratch[:0])
+
+	b = append(b, '{')
+	for n, key := range keys {
+		if n > 0 {
+			b = append(b, ',')
+		}
+		b = appendString(b, key)
+		b = append(b, ':')
+
+		var err error
+		if value, ok := j.msg[key]; ok {
+			b, err = appendValue(b, value)
+		} else {
+			b, err = appendField(b, j.fields[j.fieldIndex(key)])
+		}
+		if err != nil {
+			return b, err
+		}
+	}
+	return append(b, '}'), nil
+}
+
+// appendField appends the value of a typed field to b.
+func appendField(b []byte, f Field) ([]byte, error) {
+	switch f.kind {
+	case stringKind:
+		return appendString(b, f.str), nil
+	case int64Kind, durationKind:
+		return strconv.AppendInt(b, f.integer, 10), nil
+	case float64Kind:
+		value := math.Float64frombits(uint64(f.integer))
+		switch {
+		case math.IsNaN(value):
+			return append(b, `"NaN"`...), nil
+		case math.IsInf(value, 1):
+			return append(b, `"+Inf"`...), nil
+		case math.IsInf(value, -1):
+			return append(b, `"-Inf"`...), nil
+		}
+		return appendFloat(b, value, 64), nil
+	case boolKind:
+		return strconv.AppendBool(b, f.integer == 1), nil
+	case timeKind:
+		b = append(b, '"')
+		b = f.time.AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"'), nil
+	case errorKind:
+		if f.value == nil {
+			return append(b, "null"...), nil
+		}
+		return appendString(b, f.value.(error).Error()), nil
+	}
+	return appendValue(b, f.value)
+}
+
+// appendValue appends a value stored with Add to b. Types that are not handled directly are
+// marshalled with encoding/json.
+func appendValue(b []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case string:
+		return appendString(b, v), nil
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case int:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int8:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int16:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case uint:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint8:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint16:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint32:
+		return strconv.AppendUint(b, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint(b, v, 10), nil
+	case map[string]interface{}:
+		return appendObject(b, v)
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return appendFloat(b, v, 64), nil
+		}
+	case float32:
+		if !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0) {
+			return appendFloat(b, float64(v), 32), nil
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return b, err
+	}
+	return append(b, encoded...), nil
+}
+
+// appendObject appends a nested object, such as a Group, to b with its keys sorted.
+func appendObject(b []byte, object map[string]interface{}) ([]byte, error) {
+	if object == nil {
+		return append(b, "null"...), nil
+	}
+
+	var scratch [32]string
+	keys := scratch[:0]
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sortKeys(keys)
+
+	b = append(b, '{')
+	for n, key := range keys {
+		if n > 0 {
+			b = append(b, ',')
+		}
+		b = appendString(b, key)
+		b = append(b, ':')
+
+		var err error
+		if b, err = appendValue(b, object[key]); err != nil {
+			return b, err
+		}
+	}
+	return append(b, '}'), nil
+}
+
+// appendFloat appends f like encoding/json does, using exponents only for very large and
+// very small numbers.
+func appendFloat(b []byte, f float64, bits int) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// appendString appends s as a quoted JSON string, escaping it like encoding/json does.
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
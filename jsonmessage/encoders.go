@@ -0,0 +1,53 @@
+package jsonmessage
+
+// Encoder writes a message in some format. Printers use it in place of JSON when one is set.
+// Encode appends the encoded message to dst and returns the result like the strconv Append
+// functions, so that printers can encode straight into their pooled buffers.
+type Encoder interface {
+	Encode(dst []byte, msg *JSONMessage) []byte
+}
+
+// EncoderFunc lets a plain function be used as an Encoder.
+type EncoderFunc func(dst []byte, msg *JSONMessage) []byte
+
+// Encode calls f.
+func (f EncoderFunc) Encode(dst []byte, msg *JSONMessage) []byte {
+	return f(dst, msg)
+}
+
+// JSONEncoder writes messages as compact JSON, like AppendBytes.
+type JSONEncoder struct{}
+
+// Encode appends msg as compact JSON to dst.
+func (JSONEncoder) Encode(dst []byte, msg *JSONMessage) []byte {
+	return msg.AppendBytes(dst)
+}
+
+// PrettyJSONEncoder writes messages as indented JSON, like AppendPrettyBytes.
+type PrettyJSONEncoder struct{}
+
+// Encode appends msg as indented JSON to dst.
+func (PrettyJSONEncoder) Encode(dst []byte, msg *JSONMessage) []byte {
+	return msg.AppendPrettyBytes(dst)
+}
+
+// LogfmtEncoder writes messages as logfmt, like AppendLogfmt.
+type LogfmtEncoder struct{}
+
+// Encode appends msg as logfmt to dst.
+func (LogfmtEncoder) Encode(dst []byte, msg *JSONMessage) []byte {
+	return msg.AppendLogfmt(dst)
+}
+
+// Range calls f with every key and value in the order they would be encoded, stopping if f
+// returns false. Typed fields are passed in the form that Add would have stored them. It is
+// meant for encoders that live outside of this package.
+func (j *JSONMessage) Range(f func(key string, value interface{}) bool) {
+	var scratch [32]string
+	for _, key := range j.encodingKeys(scratch[:0]) {
+		value, _ := j.get(key)
+		if !f(key, value) {
+			return
+		}
+	}
+}
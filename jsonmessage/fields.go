@@ -0,0 +1,150 @@
+package jsonmessage
+
+import (
+	"math"
+	"time"
+)
+
+type fieldKind uint8
+
+const (
+	stringKind fieldKind = iota + 1
+	int64Kind
+	float64Kind
+	boolKind
+	durationKind
+	timeKind
+	errorKind
+	anyKind
+)
+
+// Field is a key with a typed value. Make them with String, Int64, Float64, Bool, Duration,
+// Time, Err and Any then add them with AddFields. Typed values are written straight into the
+// JSON without going through reflection, which is much cheaper than Add for busy services.
+type Field struct {
+	Key     string
+	kind    fieldKind
+	integer int64
+	str     string
+	time    time.Time
+	value   interface{}
+}
+
+// String makes a Field holding a string.
+func String(key, value string) Field {
+	return Field{Key: key, kind: stringKind, str: value}
+}
+
+// Int64 makes a Field holding an int64.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: int64Kind, integer: value}
+}
+
+// Float64 makes a Field holding a float64. NaN and infinities can't be written as JSON numbers
+// so they are written as the strings "NaN", "+Inf" and "-Inf".
+func Float64(key string, value float64) Field {
+	return Field{Key: key, kind: float64Kind, integer: int64(math.Float64bits(value))}
+}
+
+// Bool makes a Field holding a bool.
+func Bool(key string, value bool) Field {
+	f := Field{Key: key, kind: boolKind}
+	if value {
+		f.integer = 1
+	}
+	return f
+}
+
+// Duration makes a Field holding a time.Duration. It is written as a number of nanoseconds,
+// the same as Add would write it.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, kind: durationKind, integer: int64(value)}
+}
+
+// Time makes a Field holding a time.Time. It is written in RFC 3339 format with nanoseconds,
+// the same as Add would write it.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, kind: timeKind, time: value}
+}
+
+// Err makes a Field holding the message of err under JSONErrorKey, like JSONMessage.Error does.
+// A nil error is written as null.
+func Err(err error) Field {
+	return Field{Key: JSONErrorKey, kind: errorKind, value: err}
+}
+
+// Any makes a Field holding any value. The value is written with encoding/json so it costs the
+// same as Add, use one of the typed constructors if you can.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, kind: anyKind, value: value}
+}
+
+// Value returns the value of the field in the form that Add would have stored it.
+// Errors are returned as their message.
+func (f Field) Value() interface{} {
+	switch f.kind {
+	case stringKind:
+		return f.str
+	case int64Kind:
+		return f.integer
+	case float64Kind:
+		return math.Float64frombits(uint64(f.integer))
+	case boolKind:
+		return f.integer == 1
+	case durationKind:
+		return time.Duration(f.integer)
+	case timeKind:
+		return f.time
+	case errorKind:
+		if f.value == nil {
+			return nil
+		}
+		return f.value.(error).Error()
+	}
+	return f.value
+}
+
+// AddFields adds typed fields to the message. A field replaces anything already stored under
+// its key, whether it was added with Add or AddFields.
+func (j *JSONMessage) AddFields(fields ...Field) {
+	if j.fields == nil {
+		j.fields = make([]Field, 0, len(fields))
+	}
+	for _, field := range fields {
+		j.trackKey(field.Key)
+		delete(j.msg, field.Key)
+		if i := j.fieldIndex(field.Key); i >= 0 {
+			j.fields[i] = field
+			continue
+		}
+		j.fields = append(j.fields, field)
+	}
+}
+
+// fieldIndex returns the position of key in the typed fields or -1 if it is not there.
+func (j *JSONMessage) fieldIndex(key string) int {
+	for i := range j.fields {
+		if j.fields[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeField removes key from the typed fields if it is there.
+func (j *JSONMessage) removeField(key string) {
+	if i := j.fieldIndex(key); i >= 0 {
+		j.fields = append(j.fields[:i], j.fields[i+1:]...)
+	}
+}
+
+// get returns the value stored under key no matter how it was added.
+func (j *JSONMessage) get(key string) (interface{}, bool) {
+	if value, ok := j.msg[key]; ok {
+		return value, true
+	}
+	if i := j.fieldIndex(key); i >= 0 {
+		return j.fields[i].Value(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,220 @@
+package jsonmessage
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEncoderMatchesEncodingJSON(t *testing.T) {
+	values := map[string]interface{}{
+		"string":      "plain",
+		"escaped":     "quote \" slash \\ html <a href=\"x\">&</a> control \n\t\r\b\f\x01",
+		"unicode":     "héllo     \xff",
+		"bool":        true,
+		"int":         -42,
+		"uint8":       uint8(200),
+		"int64":       int64(math.MaxInt64),
+		"float":       3.14159,
+		"small float": 0.0000001,
+		"large float": 1e21,
+		"float32":     float32(1.1),
+		"nil":         nil,
+		"duration":    1500 * time.Millisecond,
+		"time":        time.Date(2019, 5, 6, 7, 8, 9, 10, time.UTC),
+		"map":         map[string]string{"a": "b"},
+		"slice":       []interface{}{1, "two"},
+		"struct":      struct{ A int }{A: 1},
+		"<html key>":  1,
+		" in key":     2,
+	}
+
+	jm := New()
+	for key, value := range values {
+		jm.Add(key, value)
+	}
+
+	want, err := json.Marshal(jm.msg)
+	if err != nil {
+		t.Fatalf("encoding/json failed. Error: %s", err)
+	}
+	if got := jm.String(); got != string(want) {
+		t.Logf("Encoder does not match encoding/json.\nGot:  %s\nWant: %s", got, want)
+		t.Fail()
+	}
+
+	wantPretty, _ := json.MarshalIndent(jm.msg, "", "    ")
+	if got := jm.PrettyString(); got != string(wantPretty) {
+		t.Logf("Pretty encoder does not match encoding/json.\nGot:  %s\nWant: %s", got, wantPretty)
+		t.Fail()
+	}
+}
+
+func TestAddFields(t *testing.T) {
+	now := time.Date(2019, 5, 6, 7, 8, 9, 10, time.UTC)
+
+	typed := New()
+	typed.AddFields(
+		String("string", "<value>"),
+		Int64("int64", -7),
+		Float64("float64", 0.5),
+		Bool("bool", true),
+		Duration("duration", time.Second),
+		Time("time", now),
+		Err(errors.New("broken")),
+		Any("any", []int{1, 2}),
+	)
+
+	untyped := New()
+	untyped.Add(JSONTimeStampKey, typed.msg[JSONTimeStampKey])
+	untyped.Add("string", "<value>")
+	untyped.Add("int64", int64(-7))
+	untyped.Add("float64", 0.5)
+	untyped.Add("bool", true)
+	untyped.Add("duration", time.Second)
+	untyped.Add("time", now)
+	untyped.Error(errors.New("broken"))
+	untyped.Add("any", []int{1, 2})
+
+	if typed.String() != untyped.String() {
+		t.Logf("Typed fields are not written like Add.\nTyped:   %s\nUntyped: %s", typed.String(), untyped.String())
+		t.Fail()
+	}
+}
+
+func TestAddFieldsReplaces(t *testing.T) {
+	jm := New()
+	jm.Add("user", "old")
+	jm.AddFields(String("user", "typed"), Int64("count", 1))
+	jm.AddFields(Int64("count", 2))
+	jm.Add("count", "untyped")
+
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(jm.Bytes(), &got); err != nil {
+		t.Fatalf("Message is not JSON. Error: %s", err)
+	}
+	if got["user"] != "typed" || got["count"] != "untyped" || len(jm.fields) != 1 {
+		t.Logf("Fields were not replaced. Got: %v", got)
+		t.Fail()
+	}
+
+	raw := jm.RawDump()
+	if raw["user"] != "typed" || len(jm.fields) != 0 {
+		t.Logf("RawDump did not move the typed fields into the map. Got: %v", raw)
+		t.Fail()
+	}
+}
+
+func TestFloat64Special(t *testing.T) {
+	jm := New()
+	jm.AddFields(Float64("nan", math.NaN()), Float64("inf", math.Inf(1)), Float64("ninf", math.Inf(-1)))
+
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(jm.Bytes(), &got); err != nil {
+		t.Fatalf("Message is not JSON. Error: %s", err)
+	}
+	if got["nan"] != "NaN" || got["inf"] != "+Inf" || got["ninf"] != "-Inf" {
+		t.Logf("Special floats were not written as strings. Got: %v", got)
+		t.Fail()
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	err := errors.New("broken")
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jm := New()
+		jm.SetInfo()
+		jm.Message("request finished")
+		jm.Add("path", "/api/v1/users")
+		jm.Add("status", int64(200))
+		jm.Add("ratio", 0.75)
+		jm.Add("cached", true)
+		jm.Add("took", 1500*time.Microsecond)
+		jm.Add("started", now)
+		jm.Error(err)
+		_ = jm.Bytes()
+	}
+}
+
+func BenchmarkAddFields(b *testing.B) {
+	err := errors.New("broken")
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jm := New()
+		jm.SetInfo()
+		jm.Message("request finished")
+		jm.AddFields(
+			String("path", "/api/v1/users"),
+			Int64("status", 200),
+			Float64("ratio", 0.75),
+			Bool("cached", true),
+			Duration("took", 1500*time.Microsecond),
+			Time("started", now),
+			Err(err),
+		)
+		_ = jm.Bytes()
+	}
+}
+
+func BenchmarkEncodingJSON(b *testing.B) {
+	err := errors.New("broken")
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jm := New()
+		jm.SetInfo()
+		jm.Message("request finished")
+		jm.Add("path", "/api/v1/users")
+		jm.Add("status", int64(200))
+		jm.Add("ratio", 0.75)
+		jm.Add("cached", true)
+		jm.Add("took", 1500*time.Microsecond)
+		jm.Add("started", now)
+		jm.Error(err)
+		_, _ = json.Marshal(jm.msg)
+	}
+}
+
+func TestAppendBytesDoesNotAllocate(t *testing.T) {
+	jm := New()
+	jm.SetInfo()
+	jm.Message("request finished")
+	jm.AddFields(String("path", "/api/v1/users"), Int64("status", 200), Duration("took", time.Second))
+	buf := make([]byte, 0, 1024)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = jm.AppendBytes(buf[:0])
+	})
+	if allocs != 0 {
+		t.Logf("AppendBytes allocated %v times per message.", allocs)
+		t.Fail()
+	}
+}
+
+func BenchmarkPooledAppendBytes(b *testing.B) {
+	err := errors.New("broken")
+	now := time.Now()
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jm := New()
+		jm.SetInfo()
+		jm.Message("request finished")
+		jm.AddFields(
+			String("path", "/api/v1/users"),
+			Int64("status", 200),
+			Float64("ratio", 0.75),
+			Bool("cached", true),
+			Duration("took", 1500*time.Microsecond),
+			Time("started", now),
+			Err(err),
+		)
+		buf = jm.AppendBytes(buf[:0])
+		jm.Release()
+	}
+}
@@ -0,0 +1,228 @@
+package jsonmessage
+
+import (
+	"reflect"
+	"strings"
+)
+
+// PathSeparator separates the names in the paths used by AddPath, Flatten and Expand.
+const PathSeparator = "."
+
+// Group is a nested object inside a JSONMessage. Groups are stored as plain
+// map[string]interface{} values so mutators find them in RawDump like any other value.
+// Nested objects that the message did not make, such as the decorations of a printer that every
+// message shares, are copied before they are written to.
+type Group struct {
+	msg    *JSONMessage
+	fields map[string]interface{}
+}
+
+// Group returns the nested object stored under name, making it if needed. Anything stored
+// under name that is not a nested object is replaced.
+func (j *JSONMessage) Group(name string) *Group {
+	if fields, ok := j.msg[name].(map[string]interface{}); ok {
+		owned := j.claim(fields)
+		j.msg[name] = owned
+		return &Group{msg: j, fields: owned}
+	}
+	fields := j.newObject(0)
+	j.Add(name, fields)
+	return &Group{msg: j, fields: fields}
+}
+
+// AddPath adds value under a dotted path, making nested objects for every name but the last.
+// AddPath("http.status", 200) is the same as Group("http").Add("status", 200).
+func (j *JSONMessage) AddPath(path string, value interface{}) {
+	names := strings.Split(path, PathSeparator)
+	if len(names) == 1 {
+		j.Add(path, value)
+		return
+	}
+
+	group := j.Group(names[0])
+	for _, name := range names[1 : len(names)-1] {
+		group = group.Group(name)
+	}
+	group.Add(names[len(names)-1], value)
+}
+
+// Add adds key and value to the group.
+func (g *Group) Add(key string, value interface{}) {
+	g.fields[key] = value
+}
+
+// AddFields adds typed fields to the group. Groups are encoded like any other nested value so
+// the fields lose their typed fast path.
+func (g *Group) AddFields(fields ...Field) {
+	for _, field := range fields {
+		g.fields[field.Key] = field.Value()
+	}
+}
+
+// Group returns the nested object stored under name inside this group, making it if needed.
+// Anything stored under name that is not a nested object is replaced.
+func (g *Group) Group(name string) *Group {
+	return &Group{msg: g.msg, fields: g.msg.subGroup(g.fields, name)}
+}
+
+// subGroup returns the nested object stored under name in fields, making it if needed.
+// fields must belong to the message.
+func (j *JSONMessage) subGroup(fields map[string]interface{}, name string) map[string]interface{} {
+	if nested, ok := fields[name].(map[string]interface{}); ok {
+		owned := j.claim(nested)
+		fields[name] = owned
+		return owned
+	}
+	nested := j.newObject(0)
+	fields[name] = nested
+	return nested
+}
+
+// newObject makes a nested object that belongs to the message so it can be written to.
+func (j *JSONMessage) newObject(size int) map[string]interface{} {
+	object := make(map[string]interface{}, size)
+	j.objects = append(j.objects, object)
+	return object
+}
+
+// claim returns object if the message made it, otherwise a copy of object that the message
+// owns. Objects that came from elsewhere may be shared with other messages so they must not be
+// written to.
+func (j *JSONMessage) claim(object map[string]interface{}) map[string]interface{} {
+	pointer := reflect.ValueOf(object).Pointer()
+	for _, owned := range j.objects {
+		if reflect.ValueOf(owned).Pointer() == pointer {
+			return object
+		}
+	}
+	copied := j.newObject(len(object))
+	for key, value := range object {
+		copied[key] = value
+	}
+	return copied
+}
+
+// Nesting decides what happens to nested objects and dotted keys when a printer writes a message.
+type Nesting int
+
+const (
+	// KeepNesting writes the message as it is.
+	KeepNesting Nesting = iota
+	// FlattenNesting turns nested objects into dotted keys, see Flatten.
+	FlattenNesting
+	// ExpandNesting turns dotted keys into nested objects, see Expand.
+	ExpandNesting
+)
+
+// Apply changes the message to match the nesting.
+func (n Nesting) Apply(j *JSONMessage) {
+	switch n {
+	case FlattenNesting:
+		j.Flatten()
+	case ExpandNesting:
+		j.Expand()
+	}
+}
+
+// Flatten replaces every nested object with dotted keys, {"http":{"status":200}} becomes
+// {"http.status":200}. The dotted keys take the place of the object in insertion order.
+func (j *JSONMessage) Flatten() {
+	keys := make([]string, 0, len(j.keys))
+	for _, key := range j.keys {
+		nested, ok := j.msg[key].(map[string]interface{})
+		if !ok {
+			keys = append(keys, key)
+			continue
+		}
+		delete(j.msg, key)
+		keys = j.flatten(keys, key, nested)
+	}
+	// Objects put straight into the map returned by RawDump have no place in the order.
+	for key, value := range j.msg {
+		if nested, ok := value.(map[string]interface{}); ok {
+			delete(j.msg, key)
+			keys = j.flatten(keys, key, nested)
+		}
+	}
+	j.keys = keys
+}
+
+func (j *JSONMessage) flatten(keys []string, prefix string, nested map[string]interface{}) []string {
+	names := make([]string, 0, len(nested))
+	for name := range nested {
+		names = append(names, name)
+	}
+	sortKeys(names)
+
+	for _, name := range names {
+		path := prefix + PathSeparator + name
+		if inner, ok := nested[name].(map[string]interface{}); ok {
+			keys = j.flatten(keys, path, inner)
+			continue
+		}
+		j.removeField(path)
+		j.msg[path] = nested[name]
+		if !containsKey(keys, path) {
+			keys = append(keys, path)
+		}
+	}
+	return keys
+}
+
+// Expand replaces every dotted key with nested objects, {"http.status":200} becomes
+// {"http":{"status":200}}. The object takes the place of the first of its keys in insertion
+// order. A dotted key is left alone if its first name already holds something that is not an
+// object so that nothing is lost.
+func (j *JSONMessage) Expand() {
+	keys := make([]string, 0, len(j.keys))
+	for _, key := range j.keys {
+		keys = j.expand(keys, key)
+	}
+	// Keys put straight into the map returned by RawDump have no place in the order.
+	for key := range j.msg {
+		if strings.Contains(key, PathSeparator) {
+			keys = j.expand(keys, key)
+		}
+	}
+	j.keys = keys
+}
+
+func (j *JSONMessage) expand(keys []string, key string) []string {
+	names := strings.Split(key, PathSeparator)
+	value, ok := j.get(key)
+	if !ok || len(names) == 1 {
+		if ok && !containsKey(keys, key) {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	top, isObject := j.msg[names[0]].(map[string]interface{})
+	if !isObject {
+		if j.Has(names[0]) {
+			// Keep the dotted key rather than throw away what is stored under its first name.
+			if !containsKey(keys, key) {
+				keys = append(keys, key)
+			}
+			return keys
+		}
+		top = j.newObject(0)
+		j.msg[names[0]] = top
+	} else {
+		top = j.claim(top)
+		j.msg[names[0]] = top
+	}
+
+	delete(j.msg, key)
+	j.removeField(key)
+	nested := top
+	for _, name := range names[1 : len(names)-1] {
+		nested = j.subGroup(nested, name)
+	}
+	nested[names[len(names)-1]] = value
+
+	if !containsKey(keys, names[0]) {
+		keys = append(keys, names[0])
+	}
+	return keys
+}
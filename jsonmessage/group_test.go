@@ -0,0 +1,117 @@
+package jsonmessage
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	jm := New()
+	http := jm.Group("http")
+	http.Add("status", 200)
+	http.AddFields(String("method", "GET"))
+	jm.Group("http").Group("request").Add("id", "abc")
+	jm.AddPath("http.request.bytes", 512)
+	jm.AddPath("flat", true)
+
+	want := `"flat":true,"http":{"method":"GET","request":{"bytes":512,"id":"abc"},"status":200}`
+	if got := jm.String(); !strings.Contains(got, want) {
+		t.Logf("Groups are not nested objects. Got: %s", got)
+		t.Fail()
+	}
+
+	// Groups must be visible to mutators as plain maps.
+	if _, ok := jm.RawDump()["http"].(map[string]interface{}); !ok {
+		t.Logf("RawDump did not return the group as a map. Got: %#v", jm.RawDump()["http"])
+		t.Fail()
+	}
+
+	// Groups must encode the same as encoding/json would.
+	expected, _ := json.Marshal(jm.RawDump())
+	if got := jm.String(); got != string(expected) {
+		t.Logf("Encoder does not match encoding/json.\nGot:  %s\nWant: %s", got, expected)
+		t.Fail()
+	}
+}
+
+func TestGroupReplacesValue(t *testing.T) {
+	jm := New()
+	jm.AddFields(Int64("http", 1))
+	jm.AddPath("http.status", 200)
+
+	if got := jm.String(); !strings.Contains(got, `"http":{"status":200}`) {
+		t.Logf("Group did not replace the value under its name. Got: %s", got)
+		t.Fail()
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	jm := New()
+	jm.SetKeyOrder(InsertionOrder)
+	jm.Add("first", 1)
+	jm.AddPath("http.status", 200)
+	jm.AddPath("http.request.id", "abc")
+	jm.Add("last", 2)
+	jm.RawDump()["raw"] = map[string]interface{}{"key": "value"}
+	jm.Flatten()
+
+	want := []string{"first", "http.request.id", "http.status", "last", "raw.key"}
+	got := jm.String()
+	if !keysInOrder(got, want) {
+		t.Logf("Flattened keys are not in place. Got: %s", got)
+		t.Fail()
+	}
+	if strings.Contains(got, `":{`) {
+		t.Logf("Message still has nested objects. Got: %s", got)
+		t.Fail()
+	}
+}
+
+func TestExpand(t *testing.T) {
+	jm := New()
+	jm.SetKeyOrder(InsertionOrder)
+	jm.Add("first", 1)
+	jm.Add("http.status", 200)
+	jm.AddFields(String("http.request.id", "abc"))
+	jm.Add("last", 2)
+	// The first name already holds a value so the dotted key can't be expanded.
+	jm.Add("host", "web1")
+	jm.Add("host.port", 80)
+	jm.RawDump()["raw.key"] = "value"
+	jm.Expand()
+
+	got := jm.String()
+	for _, want := range []string{
+		`"http":{"request":{"id":"abc"},"status":200}`,
+		`"raw":{"key":"value"}`,
+		`"host":"web1"`,
+		`"host.port":80`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Logf("Expanded message is missing %s. Got: %s", want, got)
+			t.Fail()
+		}
+	}
+	if !keysInOrder(got, []string{"first", "http", "last"}) {
+		t.Logf("Expanded object is not in place. Got: %s", got)
+		t.Fail()
+	}
+}
+
+func TestGroupCopiesSharedObjects(t *testing.T) {
+	shared := map[string]interface{}{"host": "a"}
+	jm := New()
+	jm.Add("http", shared)
+	jm.AddPath("http.status", 500)
+	jm.Group("http").Group("tls").Add("version", "1.3")
+
+	if len(shared) != 1 {
+		t.Logf("The shared object was written to. Got: %v", shared)
+		t.Fail()
+	}
+	if want := `"http":{"host":"a","status":500,"tls":{"version":"1.3"}}`; !strings.Contains(jm.String(), want) {
+		t.Logf("Wanted %s. Got: %s", want, jm.String())
+		t.Fail()
+	}
+}
@@ -1,9 +1,11 @@
 package jsonmessage
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/silverstagtech/loggos/shared"
@@ -25,25 +27,83 @@ var (
 	JSONTimeStampKeyHuman = "human_readable_timestamp"
 	// HumanTimeStampFormat is the time stamp format used for the default timestamp formating
 	HumanTimeStampFormat = "Mon Jan _2 2006 15:04:05 MST"
+	// JSONLoggerKey is used to write the name of the child logger that sent the message.
+	JSONLoggerKey = "logger"
 	// JSONErrorKey is used as the key for adding an error message
 	JSONErrorKey = "error"
+	// JSONBadKey is used by AddPairs to hold the values that could not be paired with a key.
+	JSONBadKey = "!BADKEY"
+	// JSONCallerKey is used to write where the message was logged from.
+	JSONCallerKey = "caller"
+	// JSONStacktraceKey is used to write the stack trace of the code that logged the message.
+	JSONStacktraceKey = "stacktrace"
 )
 
 // JSONMessage is a structure that will contain the message that you want to send.
 type JSONMessage struct {
-	msg map[string]interface{}
+	msg    map[string]interface{}
+	fields []Field
+	// keys holds the keys in the order they were first added.
+	keys  []string
+	order KeyOrder
+	// objects holds the nested objects that the message made, see claim.
+	objects []map[string]interface{}
+	// pooled is true for messages from Acquire that have not been released yet.
+	pooled bool
+}
+
+var messagePool = sync.Pool{
+	New: func() interface{} {
+		return &JSONMessage{
+			msg: make(map[string]interface{}, 8),
+		}
+	},
 }
 
 // New returns a empty JSONMessage ready to be populated. The timestamp will have already been
 // written.
 func New() *JSONMessage {
 	jm := &JSONMessage{
-		msg: make(map[string]interface{}),
+		msg: make(map[string]interface{}, 8),
 	}
 	jm.msg[JSONTimeStampKey] = timeStamp()
+	jm.order = DefaultKeyOrder()
+	return jm
+}
+
+// Acquire is like New but takes the message from a pool. Give it back with Release once it has
+// been sent, the printers encode messages before Send returns so that is straight afterwards.
+// The message must not be used after it has been released.
+func Acquire() *JSONMessage {
+	jm := messagePool.Get().(*JSONMessage)
+	jm.msg[JSONTimeStampKey] = timeStamp()
+	jm.order = DefaultKeyOrder()
+	jm.pooled = true
 	return jm
 }
 
+// Release empties a message from Acquire and gives it back to the pool. It does nothing for
+// messages from New or messages that have already been released.
+func (j *JSONMessage) Release() {
+	if !j.pooled {
+		return
+	}
+	j.pooled = false
+	for key := range j.msg {
+		delete(j.msg, key)
+	}
+	for i := range j.fields {
+		j.fields[i] = Field{}
+	}
+	j.fields = j.fields[:0]
+	j.keys = j.keys[:0]
+	for i := range j.objects {
+		j.objects[i] = nil
+	}
+	j.objects = j.objects[:0]
+	messagePool.Put(j)
+}
+
 func timeStamp() string {
 	if JSONTimeStampFunc == nil {
 		JSONTimeStampFunc = newStamper()
@@ -54,12 +114,35 @@ func timeStamp() string {
 
 // Add adds on the key that you want to add your message. This can be anything you want.
 func (j *JSONMessage) Add(key string, value interface{}) {
+	j.trackKey(key)
+	if len(j.fields) > 0 {
+		j.removeField(key)
+	}
 	j.msg[key] = value
 }
 
 // Addf acts like fmt.Sprintf and stores the result under the supplied key. The stored result will be a string.
 func (j *JSONMessage) Addf(key string, format string, values ...interface{}) {
-	j.msg[key] = fmt.Sprintf(format, values...)
+	j.Add(key, fmt.Sprintf(format, values...))
+}
+
+// AddPairs adds alternating keys and values, like AddPairs("user", 7, "admin", true). Keys must
+// be strings. A value without a key, because the key is not a string or the last key has no
+// value, is added to a list under JSONBadKey instead so that nothing is lost.
+func (j *JSONMessage) AddPairs(keyvals ...interface{}) {
+	var bad []interface{}
+	for i := 0; i < len(keyvals); i++ {
+		key, ok := keyvals[i].(string)
+		if !ok || i+1 == len(keyvals) {
+			bad = append(bad, keyvals[i])
+			continue
+		}
+		j.Add(key, keyvals[i+1])
+		i++
+	}
+	if len(bad) > 0 {
+		j.Add(JSONBadKey, bad)
+	}
 }
 
 // Errorf is a shortcut function that is equivalent to calling Addf(JSONErrorKey, format, values...).
@@ -72,6 +155,29 @@ func (j *JSONMessage) Error(err error) {
 	j.Add(JSONErrorKey, err.Error())
 }
 
+// SetCaller writes caller under JSONCallerKey as a group holding its file, line and function.
+func (j *JSONMessage) SetCaller(caller shared.Caller) {
+	group := j.Group(JSONCallerKey)
+	group.Add("file", caller.File)
+	group.Add("line", caller.Line)
+	group.Add("function", caller.Function)
+}
+
+// SetStacktrace writes trace under JSONStacktraceKey.
+func (j *JSONMessage) SetStacktrace(trace string) {
+	j.Add(JSONStacktraceKey, trace)
+}
+
+// SetLevel sets level to the name of level, like INFO for shared.LevelInfo.
+func (j *JSONMessage) SetLevel(level shared.Level) {
+	j.Add(JSONLevelKey, level.String())
+}
+
+// SetTrace sets level to TRACE
+func (j *JSONMessage) SetTrace() {
+	j.Add(JSONLevelKey, shared.TraceMessage)
+}
+
 // SetInfo sets level to INFO
 func (j *JSONMessage) SetInfo() {
 	j.Add(JSONLevelKey, shared.InformationMessage)
@@ -82,24 +188,39 @@ func (j *JSONMessage) SetWarn() {
 	j.Add(JSONLevelKey, shared.WarningMessage)
 }
 
+// SetError sets level to ERROR
+func (j *JSONMessage) SetError() {
+	j.Add(JSONLevelKey, shared.ErrorMessage)
+}
+
 // SetCrit sets level to CRIT
 func (j *JSONMessage) SetCrit() {
 	j.Add(JSONLevelKey, shared.CriticalMessage)
 }
 
+// SetFatal sets level to FATAL
+func (j *JSONMessage) SetFatal() {
+	j.Add(JSONLevelKey, shared.FatalMessage)
+}
+
+// SetPanic sets level to PANIC
+func (j *JSONMessage) SetPanic() {
+	j.Add(JSONLevelKey, shared.PanicMessage)
+}
+
 // SetDebug sets level to DEBUG
 func (j *JSONMessage) SetDebug() {
 	j.Add(JSONLevelKey, shared.DebugMessage)
 }
 
 // Message sets the message key with what you pass in. It works like fmt.Sprint.
-func (j JSONMessage) Message(m ...interface{}) {
+func (j *JSONMessage) Message(m ...interface{}) {
 	j.addMessage(fmt.Sprint(m...))
 }
 
 // Messagef sets the message key with what you pass in but also allows for string formatting.
 // It works like fmt.Sprintf.
-func (j JSONMessage) Messagef(format string, m ...interface{}) {
+func (j *JSONMessage) Messagef(format string, m ...interface{}) {
 	j.addMessage(fmt.Sprintf(format, m...))
 }
 
@@ -110,10 +231,16 @@ func (j *JSONMessage) addMessage(msg string) {
 // Bytes returns the []byte representation of your message. If there is a error decoding your message
 // it will still return a []byte but will contain the error message in the form of {"Error": "message"}.
 func (j *JSONMessage) Bytes() []byte {
-	var b []byte
-	b, err := json.Marshal(j.msg)
+	return j.AppendBytes(make([]byte, 0, 256))
+}
+
+// AppendBytes appends the []byte representation of your message to dst and returns the result
+// like the strconv Append functions. Encoding into a reused buffer this way does not allocate.
+// If there is a error decoding your message the error message is appended instead, like Bytes.
+func (j *JSONMessage) AppendBytes(dst []byte) []byte {
+	b, err := j.appendJSON(dst)
 	if err != nil {
-		b = []byte(fmt.Sprintf(`{"error": "%s","raw_string":"%v"}`, err, j.msg))
+		b = append(dst, fmt.Sprintf(`{"error": "%s","raw_string":"%v"}`, err, j.msg)...)
 	}
 	return b
 }
@@ -128,12 +255,20 @@ func (j *JSONMessage) String() string {
 // If there is a error decoding your message it will still return a []byte but will contain the error message
 // in the form of {\n    "Error": "message"\n}.
 func (j *JSONMessage) PrettyBytes() []byte {
-	var b []byte
-	b, err := json.MarshalIndent(j.msg, "", "    ")
+	return j.AppendPrettyBytes(nil)
+}
+
+// AppendPrettyBytes appends the indented []byte representation of your message to dst and returns
+// the result. If there is a error decoding your message the error message is appended instead,
+// like PrettyBytes.
+func (j *JSONMessage) AppendPrettyBytes(dst []byte) []byte {
+	compact, err := j.appendJSON(make([]byte, 0, 256))
 	if err != nil {
-		b = []byte(fmt.Sprintf(`{\n    "error": "%s",\n"raw_string": "%v"\n}`, err, j.msg))
+		return append(dst, fmt.Sprintf(`{\n    "error": "%s",\n"raw_string": "%v"\n}`, err, j.msg)...)
 	}
-	return b
+	b := bytes.NewBuffer(dst)
+	json.Indent(b, compact, "", "    ")
+	return b.Bytes()
 }
 
 // PrettyString returns the string presentation of your message. If there is a error decoding your message
@@ -144,7 +279,7 @@ func (j *JSONMessage) PrettyString() string {
 
 // IsDebug will return a bool which will indicate that the message is a debug message.
 func (j *JSONMessage) IsDebug() bool {
-	if v, ok := j.msg[JSONLevelKey].(string); ok {
+	if v, ok := j.getString(JSONLevelKey); ok {
 		if v == shared.DebugMessage {
 			return true
 		}
@@ -152,6 +287,17 @@ func (j *JSONMessage) IsDebug() bool {
 	return false
 }
 
+// SetTimeStamp writes the timestamp for t in place of the time the message was made. It does
+// nothing if JSONTimeStampFunc is not a JSONTimeStamperAt, so custom stamps keep their format.
+func (j *JSONMessage) SetTimeStamp(t time.Time) {
+	if JSONTimeStampFunc == nil {
+		JSONTimeStampFunc = newStamper()
+	}
+	if stamper, ok := JSONTimeStampFunc.(JSONTimeStamperAt); ok {
+		j.Add(JSONTimeStampKey, stamper.StampAt(t))
+	}
+}
+
 // AddHumanTimestamp is used to convert epoch with nanoseconds to a human timestamp.
 // The result is written to the message using the key save in JSONTimeStampKeyHuman.
 // The key under JSONTimeStampKey MUST be a int64 or the key is simply not written.
@@ -159,7 +305,7 @@ func (j *JSONMessage) IsDebug() bool {
 // else you will get a strange looking date.
 func (j *JSONMessage) AddHumanTimestamp() {
 	// Can we parse to a string?
-	t, ok := j.msg[JSONTimeStampKey].(string)
+	t, ok := j.getString(JSONTimeStampKey)
 	if !ok {
 		return
 	}
@@ -178,6 +324,28 @@ func (j *JSONMessage) AddHumanTimestamp() {
 
 // RawDump return a pointer to a JSONMessages internal data structure.
 // Used in conjunction with JSONPrinter Mutator.
+// Typed fields are moved into the map first so that everything in the message can be seen,
+// which means they are written with the slower Add path afterwards.
 func (j *JSONMessage) RawDump() map[string]interface{} {
+	for _, field := range j.fields {
+		j.msg[field.Key] = field.Value()
+	}
+	j.fields = nil
 	return j.msg
 }
+
+// Level returns the level of the message. The bool is false if the message does not have a level
+// or the level is not one that is known, in which case LevelInfo is returned.
+func (j *JSONMessage) Level() (shared.Level, bool) {
+	if v, ok := j.getString(JSONLevelKey); ok {
+		return shared.ParseLevel(v)
+	}
+	return shared.LevelInfo, false
+}
+
+// getString returns the value stored under key if it is a string.
+func (j *JSONMessage) getString(key string) (string, bool) {
+	value, _ := j.get(key)
+	v, ok := value.(string)
+	return v, ok
+}
@@ -3,7 +3,10 @@ package jsonmessage
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/silverstagtech/loggos/shared"
 )
@@ -34,6 +37,30 @@ func TestLevelSettings(t *testing.T) {
 		t.Logf("SetDebug did not set the correct level, Got: %s", jm.msg[JSONLevelKey])
 		t.Fail()
 	}
+
+	jm.SetTrace()
+	if jm.msg[JSONLevelKey] != shared.TraceMessage {
+		t.Logf("SetTrace did not set the correct level, Got: %s", jm.msg[JSONLevelKey])
+		t.Fail()
+	}
+
+	jm.SetError()
+	if jm.msg[JSONLevelKey] != shared.ErrorMessage {
+		t.Logf("SetError did not set the correct level, Got: %s", jm.msg[JSONLevelKey])
+		t.Fail()
+	}
+
+	jm.SetFatal()
+	if jm.msg[JSONLevelKey] != shared.FatalMessage {
+		t.Logf("SetFatal did not set the correct level, Got: %s", jm.msg[JSONLevelKey])
+		t.Fail()
+	}
+
+	jm.SetPanic()
+	if jm.msg[JSONLevelKey] != shared.PanicMessage {
+		t.Logf("SetPanic did not set the correct level, Got: %s", jm.msg[JSONLevelKey])
+		t.Fail()
+	}
 }
 
 func TestTimeStamping(t *testing.T) {
@@ -62,6 +89,26 @@ func TestCustomTimeStamp(t *testing.T) {
 	JSONTimeStampFunc = nil
 }
 
+func TestSetTimeStamp(t *testing.T) {
+	at := time.Date(2019, 5, 2, 21, 4, 5, 6, time.UTC)
+	jm := New()
+	jm.SetTimeStamp(at)
+	if jm.msg[JSONTimeStampKey] != strconv.FormatInt(at.UnixNano(), 10) {
+		t.Logf("Timestamp was not set to the given time. Got: %v", jm.msg[JSONTimeStampKey])
+		t.Fail()
+	}
+
+	// Custom stamps that can't stamp a given time are left alone.
+	JSONTimeStampFunc = &testTimeStamp{testString: "gofer"}
+	defer func() { JSONTimeStampFunc = nil }()
+	jm = New()
+	jm.SetTimeStamp(at)
+	if jm.msg[JSONTimeStampKey] != "gofer" {
+		t.Logf("Custom timestamp was replaced. Got: %v", jm.msg[JSONTimeStampKey])
+		t.Fail()
+	}
+}
+
 func TestAddingCustomField(t *testing.T) {
 	jm := New()
 
@@ -178,3 +225,64 @@ func TestErrorf(t *testing.T) {
 		}
 	}
 }
+
+func TestAddPairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyvals []interface{}
+		want    map[string]interface{}
+	}{
+		{
+			name:    "pairs",
+			keyvals: []interface{}{"user", 7, "admin", true},
+			want:    map[string]interface{}{"user": 7, "admin": true},
+		},
+		{
+			name:    "odd count",
+			keyvals: []interface{}{"user", 7, "admin"},
+			want:    map[string]interface{}{"user": 7, JSONBadKey: []interface{}{"admin"}},
+		},
+		{
+			name:    "key is not a string",
+			keyvals: []interface{}{1, "user", 7},
+			want:    map[string]interface{}{"user": 7, JSONBadKey: []interface{}{1}},
+		},
+	}
+
+	for _, test := range tests {
+		jm := New()
+		jm.AddPairs(test.keyvals...)
+		for key, value := range test.want {
+			if fmt.Sprint(jm.msg[key]) != fmt.Sprint(value) {
+				t.Logf("%s: %s is %v, wanted %v", test.name, key, jm.msg[key], value)
+				t.Fail()
+			}
+		}
+		if _, ok := test.want[JSONBadKey]; !ok {
+			if _, bad := jm.msg[JSONBadKey]; bad {
+				t.Logf("%s: %s should not be set. Got: %v", test.name, JSONBadKey, jm.msg[JSONBadKey])
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestAcquireRelease(t *testing.T) {
+	jm := New()
+	jm.Message("kept")
+	jm.Release()
+	if !strings.Contains(jm.String(), `"log_message":"kept"`) {
+		t.Logf("Release emptied a message from New. Got: %s", jm.String())
+		t.Fail()
+	}
+
+	pooled := Acquire()
+	pooled.Message("pooled")
+	pooled.Release()
+	// Releasing twice must not put the message in the pool twice.
+	pooled.Release()
+	if Acquire() == Acquire() {
+		t.Logf("Acquire handed out the same message twice.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,146 @@
+package jsonmessage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// AppendLogfmt appends the message to dst as logfmt, key=value pairs split by spaces, and returns
+// the result. The timestamp, level and message keys come first, the rest follow in the order of
+// the message. Nested objects are written as dotted keys, values are quoted when they need it.
+func (j *JSONMessage) AppendLogfmt(dst []byte) []byte {
+	start := len(dst)
+	var scratch [32]string
+	for _, key := range j.logfmtKeys(scratch[:0]) {
+		value, _ := j.get(key)
+		dst = appendLogfmtPair(dst, key, value)
+	}
+	// Every pair starts with a space, drop the first one.
+	if len(dst) > start {
+		copy(dst[start:], dst[start+1:])
+		dst = dst[:len(dst)-1]
+	}
+	return dst
+}
+
+// LogfmtString returns the message as logfmt, see AppendLogfmt.
+func (j *JSONMessage) LogfmtString() string {
+	return string(j.AppendLogfmt(make([]byte, 0, 256)))
+}
+
+// logfmtKeys appends the keys of the message to keys with the timestamp, level and message first.
+func (j *JSONMessage) logfmtKeys(keys []string) []string {
+	for _, key := range [...]string{JSONTimeStampKey, JSONLevelKey, JSONMessageKey} {
+		if j.Has(key) {
+			keys = append(keys, key)
+		}
+	}
+	first := len(keys)
+	// encodingKeys sorts the whole slice it is given so give it an empty one after the first keys.
+	keys = append(keys[:first], j.encodingKeys(keys[first:first])...)
+
+	// Take out the second copy of the first keys. Filtering in place is safe as nothing is
+	// written past what has been read.
+	out := keys[:first]
+	for _, key := range keys[first:] {
+		if key != JSONTimeStampKey && key != JSONLevelKey && key != JSONMessageKey {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// appendLogfmtPair appends a space and key=value to dst. Nested objects become a pair for each
+// of their values with the keys joined by dots.
+func appendLogfmtPair(dst []byte, key string, value interface{}) []byte {
+	if object, ok := value.(map[string]interface{}); ok {
+		var scratch [32]string
+		keys := scratch[:0]
+		for k := range object {
+			keys = append(keys, k)
+		}
+		sortKeys(keys)
+		for _, k := range keys {
+			dst = appendLogfmtPair(dst, key+PathSeparator+k, object[k])
+		}
+		return dst
+	}
+
+	dst = append(dst, ' ')
+	dst = appendLogfmtKey(dst, key)
+	dst = append(dst, '=')
+	return appendLogfmtValue(dst, value)
+}
+
+// appendLogfmtKey appends key with anything that would break the pair replaced by _.
+func appendLogfmtKey(dst []byte, key string) []byte {
+	if key == "" {
+		return append(dst, '_')
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			r = '_'
+		}
+		dst = append(dst, string(r)...)
+	}
+	return dst
+}
+
+// appendLogfmtValue appends value in the form people expect to read in logfmt. Durations and
+// times are written as text rather than the numbers and RFC 3339 strings JSON uses for them.
+func appendLogfmtValue(dst []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case string:
+		return appendLogfmtString(dst, v)
+	case bool:
+		return strconv.AppendBool(dst, v)
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(dst, v, 10)
+	case float64:
+		return strconv.AppendFloat(dst, v, 'g', -1, 64)
+	case float32:
+		return strconv.AppendFloat(dst, float64(v), 'g', -1, 32)
+	case time.Duration:
+		return append(dst, v.String()...)
+	case time.Time:
+		return v.AppendFormat(dst, time.RFC3339Nano)
+	case error:
+		return appendLogfmtString(dst, v.Error())
+	case fmt.Stringer:
+		return appendLogfmtString(dst, v.String())
+	}
+
+	// Anything else is written as JSON, quoted if it needs to be.
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return appendLogfmtString(dst, fmt.Sprint(value))
+	}
+	return appendLogfmtString(dst, string(encoded))
+}
+
+// appendLogfmtString appends s, quoting it if it is empty or has anything in it that would break
+// the pair.
+func appendLogfmtString(dst []byte, s string) []byte {
+	if s == "" {
+		return append(dst, `""`...)
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !strconv.IsPrint(r) {
+			return strconv.AppendQuote(dst, s)
+		}
+	}
+	return append(dst, s...)
+}
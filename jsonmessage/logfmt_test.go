@@ -0,0 +1,75 @@
+package jsonmessage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogfmt(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+		want  string
+	}{
+		{name: "bare string", key: "user", value: "alice", want: "user=alice"},
+		{name: "empty string", key: "user", value: "", want: `user=""`},
+		{name: "spaces", key: "msg", value: "two words", want: `msg="two words"`},
+		{name: "quotes", key: "msg", value: `say "hi"`, want: `msg="say \"hi\""`},
+		{name: "equals", key: "query", value: "a=b", want: `query="a=b"`},
+		{name: "new line", key: "msg", value: "a\nb", want: `msg="a\nb"`},
+		{name: "unicode", key: "city", value: "Zürich", want: "city=Zürich"},
+		{name: "bad key", key: "bad key=", value: 1, want: "bad_key_=1"},
+		{name: "int", key: "status", value: 200, want: "status=200"},
+		{name: "float", key: "ratio", value: 0.5, want: "ratio=0.5"},
+		{name: "bool", key: "ok", value: true, want: "ok=true"},
+		{name: "nil", key: "thing", value: nil, want: "thing=null"},
+		{name: "duration", key: "took", value: 1500 * time.Millisecond, want: "took=1.5s"},
+		{name: "error", key: "err", value: errors.New("no such file"), want: `err="no such file"`},
+		{name: "slice", key: "tags", value: []string{"a", "b"}, want: `tags="[\"a\",\"b\"]"`},
+		{name: "nested", key: "http", value: map[string]interface{}{"status": 200, "req": map[string]interface{}{"id": "x"}}, want: "http.req.id=x http.status=200"},
+	}
+
+	for _, test := range tests {
+		jm := New()
+		jm.Add(test.key, test.value)
+		got := string(jm.AppendLogfmt(nil))
+		if !strings.HasSuffix(got, " "+test.want) {
+			t.Logf("%s: wanted the message to end with %s. Got: %s", test.name, test.want, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestLogfmtOrder(t *testing.T) {
+	for _, order := range []KeyOrder{SortedKeys, InsertionOrder} {
+		jm := New()
+		jm.SetKeyOrder(order)
+		jm.Add("zebra", 1)
+		jm.AddFields(String("apple", "a"))
+		jm.Message("hello world")
+		jm.SetWarn()
+
+		got := jm.LogfmtString()
+		want := []string{JSONTimeStampKey + "=", JSONLevelKey + "=WARN", JSONMessageKey + `="hello world"`}
+		if order == SortedKeys {
+			want = append(want, "apple=a", "zebra=1")
+		} else {
+			want = append(want, "zebra=1", "apple=a")
+		}
+		if strings.Count(got, "=") != len(want) {
+			t.Fatalf("Wanted %d pairs. Got: %s", len(want), got)
+		}
+		last := -1
+		for _, pair := range want {
+			i := strings.Index(got, pair)
+			if i <= last {
+				t.Logf("%s is not in order. Got: %s", pair, got)
+				t.Fail()
+			}
+			last = i
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package jsonmessage
+
+import "sync/atomic"
+
+// KeyOrder decides the order that the keys of a message are written in.
+type KeyOrder int32
+
+const (
+	// SortedKeys writes the keys in alphabetical order, the same as encoding/json does.
+	SortedKeys KeyOrder = iota
+	// InsertionOrder writes the keys under JSONTimeStampKey, JSONLevelKey and JSONMessageKey
+	// first, followed by the rest of the keys in the order they were first added. Keys put
+	// straight into the map returned by RawDump have no order so they go last, sorted.
+	InsertionOrder
+)
+
+var defaultKeyOrder int32
+
+// SetDefaultKeyOrder sets the KeyOrder of the messages made by New from now on. The default
+// is SortedKeys. It is safe to call from any goroutine.
+func SetDefaultKeyOrder(order KeyOrder) {
+	atomic.StoreInt32(&defaultKeyOrder, int32(order))
+}
+
+// DefaultKeyOrder returns the KeyOrder that New gives to messages.
+func DefaultKeyOrder() KeyOrder {
+	return KeyOrder(atomic.LoadInt32(&defaultKeyOrder))
+}
+
+// SetKeyOrder changes the order that the keys of this message are written in by String,
+// PrettyString and the other encoding methods.
+func (j *JSONMessage) SetKeyOrder(order KeyOrder) {
+	j.order = order
+}
+
+// trackKey remembers when key was first added.
+func (j *JSONMessage) trackKey(key string) {
+	if !containsKey(j.keys, key) {
+		j.keys = append(j.keys, key)
+	}
+}
+
+// Has tells you if there is anything stored under key, however it was added.
+func (j *JSONMessage) Has(key string) bool {
+	if _, ok := j.msg[key]; ok {
+		return true
+	}
+	return j.fieldIndex(key) >= 0
+}
+
+// encodingKeys appends the keys of the message to keys in the order they should be written.
+func (j *JSONMessage) encodingKeys(keys []string) []string {
+	if j.order != InsertionOrder {
+		for key := range j.msg {
+			keys = append(keys, key)
+		}
+		for i := range j.fields {
+			keys = append(keys, j.fields[i].Key)
+		}
+		sortKeys(keys)
+		return keys
+	}
+
+	for _, key := range [...]string{JSONTimeStampKey, JSONLevelKey, JSONMessageKey} {
+		if j.Has(key) && !containsKey(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range j.keys {
+		if key == JSONTimeStampKey || key == JSONLevelKey || key == JSONMessageKey || !j.Has(key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) < len(j.msg)+len(j.fields) {
+		known := len(keys)
+		for key := range j.msg {
+			if !containsKey(keys[:known], key) {
+				keys = append(keys, key)
+			}
+		}
+		sortKeys(keys[known:])
+	}
+	return keys
+}
+
+// sortKeys is an insertion sort, which is quicker than sort.Strings for the few keys that a
+// message has and does not make keys escape to the heap.
+func sortKeys(keys []string) {
+	for i := 1; i < len(keys); i++ {
+		for n := i; n > 0 && keys[n] < keys[n-1]; n-- {
+			keys[n], keys[n-1] = keys[n-1], keys[n]
+		}
+	}
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package jsonmessage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInsertionOrder(t *testing.T) {
+	jm := New()
+	jm.SetKeyOrder(InsertionOrder)
+	jm.Add("zebra", 1)
+	jm.AddFields(String("apple", "a"))
+	jm.Message("hello")
+	jm.SetInfo()
+	jm.Add("mango", true)
+	// Adding a key again keeps its first place.
+	jm.Add("zebra", 2)
+	jm.RawDump()["banana"] = "b"
+	jm.RawDump()["aardvark"] = "c"
+
+	want := []string{JSONTimeStampKey, JSONLevelKey, JSONMessageKey, "zebra", "apple", "mango", "aardvark", "banana"}
+	got := jm.String()
+	if !keysInOrder(got, want) {
+		t.Logf("Keys are not in insertion order. Got: %s", got)
+		t.Fail()
+	}
+
+	pretty := jm.PrettyString()
+	if !keysInOrder(pretty, want) {
+		t.Logf("Pretty keys are not in insertion order. Got: %s", pretty)
+		t.Fail()
+	}
+}
+
+func TestDefaultKeyOrder(t *testing.T) {
+	defer SetDefaultKeyOrder(SortedKeys)
+
+	SetDefaultKeyOrder(InsertionOrder)
+	jm := New()
+	jm.Add("b", 1)
+	jm.Add("a", 2)
+	jm.SetWarn()
+	if got := jm.String(); !keysInOrder(got, []string{JSONTimeStampKey, JSONLevelKey, "b", "a"}) {
+		t.Logf("Default key order was not used. Got: %s", got)
+		t.Fail()
+	}
+
+	SetDefaultKeyOrder(SortedKeys)
+	jm = New()
+	jm.Add("b", 1)
+	jm.Add("a", 2)
+	if got := jm.String(); !keysInOrder(got, []string{"a", "b", JSONTimeStampKey}) {
+		t.Logf("Keys are not sorted. Got: %s", got)
+		t.Fail()
+	}
+}
+
+// keysInOrder tells you if each key appears in out after the one before it.
+func keysInOrder(out string, keys []string) bool {
+	last := -1
+	for _, key := range keys {
+		i := strings.Index(out, `"`+key+`"`)
+		if i <= last {
+			return false
+		}
+		last = i
+	}
+	return true
+}
@@ -1,7 +1,7 @@
 package jsonmessage
 
 import (
-	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -10,6 +10,13 @@ type JSONTimeStamper interface {
 	Stamp() string
 }
 
+// JSONTimeStamperAt is a JSONTimeStamper that can also stamp a time other than now, such as
+// the time a slog record was made. See JSONMessage.SetTimeStamp.
+type JSONTimeStamperAt interface {
+	JSONTimeStamper
+	StampAt(time.Time) string
+}
+
 type timeStamper struct{}
 
 func newStamper() *timeStamper {
@@ -18,9 +25,13 @@ func newStamper() *timeStamper {
 
 // timeStampEpochNano returns the time now to be used as a time stamp.
 func (s *timeStamper) timeStampEpochNano() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
 }
 
 func (s *timeStamper) Stamp() string {
 	return s.timeStampEpochNano()
 }
+
+func (s *timeStamper) StampAt(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
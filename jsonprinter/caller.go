@@ -0,0 +1,73 @@
+package jsonprinter
+
+import (
+	"context"
+
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// EnableCaller makes the printer write where each message was logged from under
+// jsonmessage.JSONCallerKey as a group holding the file, line and function. Messages that
+// already have something under jsonmessage.JSONCallerKey are left alone.
+func (j *JSONPrinter) EnableCaller(toggle bool) {
+	j.updateConfig(func(c *config) { c.caller = toggle })
+}
+
+// SetCallerSkip sets how many extra frames to skip when finding the caller and the stack trace.
+// Use it when the printer is called through your own logging functions so that the caller is
+// the code that called them rather than the functions themselves. It applies to every message,
+// use LogDepth, SendDepth and SendContextDepth to skip frames for some calls only.
+func (j *JSONPrinter) SetCallerSkip(skip int) {
+	j.updateConfig(func(c *config) { c.callerSkip = skip })
+}
+
+// LogDepth is like Log but skips depth more frames when finding the caller and the stack trace.
+// Use it from your own logging functions, a depth of 1 reports the code that called them.
+func (j *JSONPrinter) LogDepth(depth int, level shared.Level, msg string, keyvals ...interface{}) {
+	j.log(1+depth, level, msg, keyvals)
+}
+
+// SendDepth is like Send but skips depth more frames when finding the caller and the stack
+// trace, see LogDepth.
+func (j *JSONPrinter) SendDepth(depth int, msg *jsonmessage.JSONMessage) {
+	c := j.loadConfig()
+	j.dispatch(nil, c, c.level, nil, 1+depth, msg)
+}
+
+// SendContextDepth is like SendContext but skips depth more frames when finding the caller and
+// the stack trace, see LogDepth.
+func (j *JSONPrinter) SendContextDepth(ctx context.Context, depth int, msg *jsonmessage.JSONMessage) {
+	c := j.loadConfig()
+	j.dispatch(ctx, c, c.level, nil, 1+depth, msg)
+}
+
+// EnableStacktraces makes the printer write a stack trace under jsonmessage.JSONStacktraceKey
+// for messages at level or above.
+func (j *JSONPrinter) EnableStacktraces(level shared.Level) {
+	j.updateConfig(func(c *config) {
+		c.stacktraces = true
+		c.stacktraceLevel = level
+	})
+}
+
+// DisableStacktraces stops the printer writing stack traces.
+func (j *JSONPrinter) DisableStacktraces() {
+	j.updateConfig(func(c *config) { c.stacktraces = false })
+}
+
+// annotate adds the caller and stack trace to msg if the printer has been asked for them.
+// depth is the number of frames between dispatch and the code that logged the message.
+func annotate(c *config, level shared.Level, depth int, msg *jsonmessage.JSONMessage) {
+	// Skip annotate and dispatch as well.
+	skip := 2 + depth + c.callerSkip
+	// Messages that already know where they came from, like those from the slog handler, keep it.
+	if c.caller && !msg.Has(jsonmessage.JSONCallerKey) {
+		if caller, ok := shared.CallerAt(skip); ok {
+			msg.SetCaller(caller)
+		}
+	}
+	if c.stacktraces && level >= c.stacktraceLevel {
+		msg.SetStacktrace(shared.Stacktrace(skip))
+	}
+}
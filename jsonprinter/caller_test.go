@@ -0,0 +1,110 @@
+package jsonprinter
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+func TestCaller(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.EnableCaller(true)
+	child := jp.Named("child")
+
+	// Every way in to the printer must report this file and the line it was called from.
+	lines := []int{}
+	here := func() int {
+		caller, _ := shared.CallerAt(1)
+		return caller.Line + 1
+	}
+	lines = append(lines, here())
+	jp.Send(jsonmessage.New())
+	lines = append(lines, here())
+	jp.Info("info")
+	lines = append(lines, here())
+	jp.Log(shared.LevelWarn, "log")
+	lines = append(lines, here())
+	jp.SendContext(context.Background(), jsonmessage.New())
+	lines = append(lines, here())
+	child.Send(jsonmessage.New())
+	lines = append(lines, here())
+	child.Crit("crit")
+	lines = append(lines, here())
+	child.SendContext(context.Background(), jsonmessage.New())
+	<-jp.Flush()
+
+	if tracing.Len() != len(lines) {
+		t.Fatalf("Wanted %d messages. Got: %v", len(lines), tracing.Show())
+	}
+	for i, line := range lines {
+		out := struct {
+			Caller struct {
+				File     string
+				Line     int
+				Function string
+			}
+		}{}
+		if err := json.Unmarshal([]byte(tracing.Show()[i]), &out); err != nil {
+			t.Fatalf("Message is not JSON. Error: %s", err)
+		}
+		if out.Caller.File != "jsonprinter/caller_test.go" || out.Caller.Line != line || out.Caller.Function != "jsonprinter.TestCaller" {
+			t.Logf("Message %d has the wrong caller, wanted line %d. Got: %s", i, line, tracing.Show()[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestCallerSkip(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.EnableCaller(true)
+	jp.SetCallerSkip(1)
+
+	wrapper := func(msg string) {
+		jp.Info(msg)
+	}
+	wrapper("skipped")
+	<-jp.Flush()
+
+	if got := tracing.Show(); len(got) != 1 || !strings.Contains(got[0], `"function":"jsonprinter.TestCallerSkip"`) {
+		t.Logf("The wrapper was not skipped. Got: %v", got)
+		t.Fail()
+	}
+}
+
+func TestStacktraces(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.EnableStacktraces(shared.LevelError)
+
+	jp.Warn("no trace")
+	jp.Error("trace")
+	<-jp.Flush()
+
+	got := tracing.Show()
+	if len(got) != 2 {
+		t.Fatalf("Wanted 2 messages. Got: %v", got)
+	}
+	if strings.Contains(got[0], jsonmessage.JSONStacktraceKey) {
+		t.Logf("Message below the stack trace level has a stack trace. Got: %s", got[0])
+		t.Fail()
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(got[1]), &out); err != nil {
+		t.Fatalf("Message is not JSON. Error: %s", err)
+	}
+	trace, _ := out[jsonmessage.JSONStacktraceKey].(string)
+	if !strings.HasPrefix(trace, "github.com/silverstagtech/loggos/jsonprinter.TestStacktraces\n") {
+		t.Logf("Stack trace does not start at the caller. Got: %s", trace)
+		t.Fail()
+	}
+}
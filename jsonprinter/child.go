@@ -0,0 +1,130 @@
+package jsonprinter
+
+import (
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// Child is a printer made with Named or With that sends its messages through the JSONPrinter
+// that made it. It shares the buffer, printing goroutine, decorations, mutators and statistics
+// of that printer. Its fields are added to every message after the decorations of the printer,
+// followed by its name under jsonmessage.JSONLoggerKey. Apart from its level a Child never
+// changes after it is made so it is cheap to make and safe to use from any goroutine.
+type Child struct {
+	level   shared.LevelOverride
+	printer *JSONPrinter
+	parent  *Child
+	name    string
+	fields  map[string]interface{}
+}
+
+// Named returns a child printer that adds name to its messages. The child uses the level of
+// the printer until it is given its own with SetLevel.
+func (j *JSONPrinter) Named(name string) *Child {
+	return &Child{
+		printer: j,
+		name:    name,
+	}
+}
+
+// Named returns a child of this child. The names are joined with a dot, so Named("pool") on
+// a child called db sends db.pool. The new child uses the level of this child until it is
+// given its own.
+func (c *Child) Named(name string) *Child {
+	if c.name != "" {
+		name = c.name + "." + name
+	}
+	return &Child{
+		printer: c.printer,
+		parent:  c,
+		name:    name,
+		fields:  c.fields,
+	}
+}
+
+// With returns a child printer that adds fields to every message it sends. The fields win
+// over the decorations of the printer and the keys in the message. The map is copied so
+// changing it afterwards will not change the child.
+func (j *JSONPrinter) With(fields map[string]interface{}) *Child {
+	return &Child{
+		printer: j,
+		fields:  mergeFields(nil, fields),
+	}
+}
+
+// With returns a child of this child that adds fields on top of the fields of this child,
+// replacing any with the same key. The new child keeps the name of this child and uses its
+// level until it is given its own.
+func (c *Child) With(fields map[string]interface{}) *Child {
+	return &Child{
+		printer: c.printer,
+		parent:  c,
+		name:    c.name,
+		fields:  mergeFields(c.fields, fields),
+	}
+}
+
+// mergeFields makes a new map holding base with extra on top. The maps are only read so
+// base can be shared with the child that it came from.
+func mergeFields(base, extra map[string]interface{}) map[string]interface{} {
+	if len(extra) == 0 {
+		return base
+	}
+	merged := make(map[string]interface{}, len(base)+len(extra))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
+
+// Name returns the name that the child adds to its messages.
+func (c *Child) Name() string {
+	return c.name
+}
+
+// SetLevel sets the lowest level of message that this child will print. It can be changed
+// at any time and does not change the level of the printer or any other child.
+func (c *Child) SetLevel(level shared.Level) {
+	c.level.Set(level)
+}
+
+// ResetLevel removes the level set with SetLevel so the child goes back to using the level
+// of its parent.
+func (c *Child) ResetLevel() {
+	c.level.Reset()
+}
+
+// Level returns the lowest level of message that this child will print.
+func (c *Child) Level() shared.Level {
+	if level, ok := c.level.Get(); ok {
+		return level
+	}
+	if c.parent != nil {
+		return c.parent.Level()
+	}
+	return c.printer.Level()
+}
+
+// Enabled tells you if a message of the given level would be printed by this child.
+func (c *Child) Enabled(level shared.Level) bool {
+	return level >= c.Level()
+}
+
+// Send takes a pointer to a JSONMessage, adds the fields and name of the child and sends it to
+// the printer. Messages below the level of the child are thrown away.
+func (c *Child) Send(msg *jsonmessage.JSONMessage) {
+	c.printer.dispatch(nil, c.printer.loadConfig(), c.Level(), c, 1, msg)
+}
+
+// decorate adds the fields and the name of the child to the message.
+func (c *Child) decorate(msg *jsonmessage.JSONMessage) {
+	for key, value := range c.fields {
+		msg.Add(key, value)
+	}
+	if c.name != "" {
+		msg.Add(jsonmessage.JSONLoggerKey, c.name)
+	}
+}
@@ -0,0 +1,152 @@
+package jsonprinter
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+func TestNamed(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.AddDecoration(map[string]interface{}{"service": "api"})
+
+	db := jp.Named("db")
+	pool := db.Named("pool")
+	if pool.Name() != "db.pool" {
+		t.Logf("Nested child has the wrong name. Got: %s", pool.Name())
+		t.Fail()
+	}
+
+	jm := jsonmessage.New()
+	jm.SetInfo()
+	jm.Message("Test named message.")
+	pool.Send(jm)
+	<-jp.Flush()
+
+	if tracing.Len() != 1 {
+		t.Fatalf("Child did not print through the parent. Got: %v", tracing.Show())
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(tracing.Show()[0]), &out); err != nil {
+		t.Fatalf("Child message is not JSON. Error: %s", err)
+	}
+	if out[jsonmessage.JSONLoggerKey] != "db.pool" || out["service"] != "api" {
+		t.Logf("Child message is missing its name or the parent decorations. Got: %v", out)
+		t.Fail()
+	}
+}
+
+func TestNamedLevel(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+
+	db := jp.Named("db")
+	pool := db.Named("pool")
+	db.SetLevel(shared.LevelDebug)
+
+	if jp.Enabled(shared.LevelDebug) || !db.Enabled(shared.LevelDebug) || !pool.Enabled(shared.LevelDebug) {
+		t.Logf("Child level override was not applied. Parent: %s, child: %s, grandchild: %s", jp.Level(), db.Level(), pool.Level())
+		t.Fail()
+	}
+
+	pool.SetLevel(shared.LevelWarn)
+	db.ResetLevel()
+	if db.Level() != shared.LevelInfo || pool.Level() != shared.LevelWarn {
+		t.Logf("Child levels did not change at runtime. Child: %s, grandchild: %s", db.Level(), pool.Level())
+		t.Fail()
+	}
+
+	for _, child := range []*Child{db, pool} {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jm.Message("Test level message.")
+		child.Send(jm)
+	}
+	<-jp.Flush()
+
+	if tracing.Len() != 1 {
+		t.Logf("Wanted only the message from db. Got: %v", tracing.Show())
+		t.Fail()
+	}
+	if stats := jp.Stats(); stats.LevelFiltered != 1 {
+		t.Logf("Child filtered message was not counted on the parent. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestWith(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.AddDecoration(map[string]interface{}{"service": "api", "request_id": "none"})
+
+	fields := map[string]interface{}{"request_id": "abc"}
+	request := jp.With(fields)
+	// Changing the map afterwards must not change the child.
+	fields["request_id"] = "changed"
+	user := request.With(map[string]interface{}{"user": 7}).Named("auth")
+
+	for _, child := range []*Child{request, user} {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jm.Message("Test with message.")
+		child.Send(jm)
+	}
+	<-jp.Flush()
+
+	if tracing.Len() != 2 {
+		t.Fatalf("With children did not print through the parent. Got: %v", tracing.Show())
+	}
+
+	expected := []map[string]interface{}{
+		{"service": "api", "request_id": "abc"},
+		{"service": "api", "request_id": "abc", "user": float64(7), jsonmessage.JSONLoggerKey: "auth"},
+	}
+	for i, want := range expected {
+		out := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(tracing.Show()[i]), &out); err != nil {
+			t.Fatalf("Child message is not JSON. Error: %s", err)
+		}
+		for key, value := range want {
+			if out[key] != value {
+				t.Logf("Message %d has %s set to %v, wanted %v.", i, key, out[key], value)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestWithConcurrent(t *testing.T) {
+	jp := New(1000)
+	jp.OverridePrinter(gotracer.New())
+	base := jp.With(map[string]interface{}{"request_id": "abc"})
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child := base.With(map[string]interface{}{"worker": i})
+			for n := 0; n < 50; n++ {
+				jm := jsonmessage.New()
+				jm.SetInfo()
+				jm.Message("Test concurrent message.")
+				child.Send(jm)
+			}
+		}(i)
+	}
+	wg.Wait()
+	<-jp.Flush()
+
+	if stats := jp.Stats(); stats.Accepted != 500 {
+		t.Logf("Not all messages from the children were accepted. Stats: %+v", stats)
+		t.Fail()
+	}
+}
@@ -0,0 +1,46 @@
+package jsonprinter
+
+import (
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/overrides"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// config holds the settings of a JSONPrinter. A stored config is never changed, setters take
+// a copy, change the copy and then store it. This lets the Send path and the printing goroutine
+// read the settings without taking a lock.
+type config struct {
+	level             shared.Level
+	printPretty       bool
+	auditmode         bool
+	humanTimestamps   bool
+	transportOverride overrides.Overrider
+	transport         overrides.Transport
+	flushFallback     overrides.Overrider
+	decorations       []map[string]interface{}
+	mutatorList       []Mutator
+	contextExtractors []ContextExtractor
+	nesting           jsonmessage.Nesting
+	encoder           jsonmessage.Encoder
+	caller            bool
+	callerSkip        int
+	stacktraces       bool
+	stacktraceLevel   shared.Level
+	colour            shared.ColourMode
+}
+
+// loadConfig returns the current settings. The returned config must not be changed.
+func (j *JSONPrinter) loadConfig() *config {
+	return j.config.Load().(*config)
+}
+
+// updateConfig copies the current settings, passes the copy to change and then stores it.
+// Setters are serialised so that two of them can't lose each others changes.
+func (j *JSONPrinter) updateConfig(change func(*config)) {
+	j.configLock.Lock()
+	defer j.configLock.Unlock()
+
+	c := *j.loadConfig()
+	change(&c)
+	j.config.Store(&c)
+}
@@ -0,0 +1,91 @@
+package jsonprinter
+
+import (
+	"context"
+
+	"github.com/silverstagtech/loggos/jsonmessage"
+)
+
+type contextKey int
+
+const (
+	fieldsKey contextKey = iota
+	childKey
+)
+
+// ContextExtractor pulls fields out of a context.Context for SendContext. Use it to map your
+// own context keys, such as a trace ID set by a tracing library, into message fields.
+// Returning nil adds nothing.
+type ContextExtractor interface {
+	Extract(context.Context) map[string]interface{}
+}
+
+// ContextExtractorFunc lets a plain function be used as a ContextExtractor.
+type ContextExtractorFunc func(context.Context) map[string]interface{}
+
+// Extract calls f(ctx).
+func (f ContextExtractorFunc) Extract(ctx context.Context) map[string]interface{} {
+	return f(ctx)
+}
+
+// ContextWithFields returns a copy of ctx that carries fields for SendContext to add to
+// messages. Fields already in ctx are kept unless fields has the same key. The map is copied
+// so changing it afterwards will not change the context.
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	return context.WithValue(ctx, fieldsKey, mergeFields(FieldsFromContext(ctx), fields))
+}
+
+// FieldsFromContext returns the fields stored in ctx with ContextWithFields. The map must not
+// be changed.
+func FieldsFromContext(ctx context.Context) map[string]interface{} {
+	fields, _ := ctx.Value(fieldsKey).(map[string]interface{})
+	return fields
+}
+
+// ContextWithChild returns a copy of ctx that carries child so that code further down can log
+// through it with ChildFromContext.
+func ContextWithChild(ctx context.Context, child *Child) context.Context {
+	return context.WithValue(ctx, childKey, child)
+}
+
+// ChildFromContext returns the child stored in ctx with ContextWithChild. The bool is false if
+// there is none.
+func ChildFromContext(ctx context.Context) (*Child, bool) {
+	child, ok := ctx.Value(childKey).(*Child)
+	return child, ok && child != nil
+}
+
+// AddContextExtractor adds an extractor that is called for every message sent with SendContext.
+// Extractors run after the fields stored with ContextWithFields so they win when keys clash.
+func (j *JSONPrinter) AddContextExtractor(extractor ContextExtractor) {
+	j.updateConfig(func(c *config) {
+		extractors := make([]ContextExtractor, len(c.contextExtractors), len(c.contextExtractors)+1)
+		copy(extractors, c.contextExtractors)
+		c.contextExtractors = append(extractors, extractor)
+	})
+}
+
+// SendContext is like Send but also adds the fields carried by ctx. They are added after the
+// decorations of the printer so they win over them.
+func (j *JSONPrinter) SendContext(ctx context.Context, msg *jsonmessage.JSONMessage) {
+	c := j.loadConfig()
+	j.dispatch(ctx, c, c.level, nil, 1, msg)
+}
+
+// SendContext is like Send but also adds the fields carried by ctx. They are added after the
+// decorations of the printer but before the fields of the child.
+func (c *Child) SendContext(ctx context.Context, msg *jsonmessage.JSONMessage) {
+	c.printer.dispatch(ctx, c.printer.loadConfig(), c.Level(), c, 1, msg)
+}
+
+// addContextFields adds the fields stored in ctx followed by the fields of the extractors.
+func addContextFields(ctx context.Context, c *config, msg *jsonmessage.JSONMessage) {
+	for key, value := range FieldsFromContext(ctx) {
+		msg.Add(key, value)
+	}
+	for _, extractor := range c.contextExtractors {
+		for key, value := range extractor.Extract(ctx) {
+			msg.Add(key, value)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package jsonprinter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/jsonmessage"
+)
+
+type traceIDKey struct{}
+
+func TestSendContext(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.AddDecoration(map[string]interface{}{"tenant": "none"})
+	jp.AddContextExtractor(ContextExtractorFunc(func(ctx context.Context) map[string]interface{} {
+		if id, ok := ctx.Value(traceIDKey{}).(string); ok {
+			return map[string]interface{}{"trace_id": id}
+		}
+		return nil
+	}))
+
+	ctx := ContextWithFields(context.Background(), map[string]interface{}{"request_id": "abc"})
+	ctx = ContextWithFields(ctx, map[string]interface{}{"tenant": "acme"})
+	ctx = context.WithValue(ctx, traceIDKey{}, "t-1")
+
+	jm := jsonmessage.New()
+	jm.SetInfo()
+	jm.Message("Test context message.")
+	jp.SendContext(ctx, jm)
+
+	// Plain Send ignores the context fields.
+	jm = jsonmessage.New()
+	jm.SetInfo()
+	jm.Message("Test message.")
+	jp.Send(jm)
+	<-jp.Flush()
+
+	if tracing.Len() != 2 {
+		t.Fatalf("Wanted 2 messages. Got: %v", tracing.Show())
+	}
+
+	expected := []map[string]interface{}{
+		{"request_id": "abc", "tenant": "acme", "trace_id": "t-1"},
+		{"request_id": nil, "tenant": "none", "trace_id": nil},
+	}
+	for i, want := range expected {
+		out := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(tracing.Show()[i]), &out); err != nil {
+			t.Fatalf("Message is not JSON. Error: %s", err)
+		}
+		for key, value := range want {
+			if out[key] != value {
+				t.Logf("Message %d has %s set to %v, wanted %v.", i, key, out[key], value)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestChildFromContext(t *testing.T) {
+	if _, ok := ChildFromContext(context.Background()); ok {
+		t.Logf("Empty context should not carry a child.")
+		t.Fail()
+	}
+
+	jp := New(10)
+	defer func() { <-jp.Flush() }()
+	child := jp.Named("db")
+	found, ok := ChildFromContext(ContextWithChild(context.Background(), child))
+	if !ok || found != child {
+		t.Logf("Child was not found in the context.")
+		t.Fail()
+	}
+}
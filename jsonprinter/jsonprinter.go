@@ -1,7 +1,10 @@
 package jsonprinter
 
 import (
-	"fmt"
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/silverstagtech/loggos/jsonmessage"
 	"github.com/silverstagtech/loggos/overrides"
@@ -13,11 +16,41 @@ type JSONLogger interface {
 	EnablePrettyPrint(bool)
 	EnableAuditMode(bool)
 	EnableHumanTimestamps(bool)
+	SetNesting(jsonmessage.Nesting)
+	SetEncoder(jsonmessage.Encoder)
+	SetColourMode(shared.ColourMode)
+	EnableCaller(bool)
+	SetCallerSkip(int)
+	EnableStacktraces(shared.Level)
+	DisableStacktraces()
+	Writer(shared.Level) *shared.LineWriter
 	AddDecoration(map[string]interface{})
 	AddMutator(Mutator)
 	OverridePrinter(overrides.Overrider)
+	OverrideTransport(overrides.Transport)
+	SetLevel(shared.Level)
+	Level() shared.Level
+	Enabled(shared.Level) bool
 	Send(*jsonmessage.JSONMessage)
 	Flush() chan bool
+	Sync() chan bool
+	FlushContext(context.Context) (shared.FlushReport, error)
+	SetFlushFallback(overrides.Overrider)
+	Stats() shared.Stats
+	Named(string) *Child
+	With(map[string]interface{}) *Child
+	SendContext(context.Context, *jsonmessage.JSONMessage)
+	AddContextExtractor(ContextExtractor)
+	Log(shared.Level, string, ...interface{})
+	Trace(string, ...interface{})
+	Debug(string, ...interface{})
+	Info(string, ...interface{})
+	Warn(string, ...interface{})
+	Error(string, ...interface{})
+	Crit(string, ...interface{})
+	LogDepth(int, shared.Level, string, ...interface{})
+	SendDepth(int, *jsonmessage.JSONMessage)
+	SendContextDepth(context.Context, int, *jsonmessage.JSONMessage)
 }
 
 // DebugJSONLogger allowed you to also toggle debug messages on and off while also pulling in JSONLogger
@@ -28,148 +61,343 @@ type DebugJSONLogger interface {
 
 // JSONPrinter consumes JSON Logs and sends them to the current output
 type JSONPrinter struct {
-	printDebug        bool
-	logsToPrint       chan string
-	FinishedChan      chan bool
-	shutdown          bool
-	printPretty       bool
-	auditmode         bool
-	droppedMessages   int64
-	transportOverride overrides.Overrider
-	decorations       []map[string]interface{}
-	humanTimestamps   bool
-	mutatorList       []Mutator
+	// stats is kept first so that its counters are 64 bit aligned for atomic access.
+	stats        shared.Counters
+	lifecycle    shared.Lifecycle
+	inFlight     int32
+	ctx          context.Context
+	cancel       context.CancelFunc
+	logsToPrint  chan shared.Entry
+	FinishedChan chan bool
+	config       atomic.Value
+	configLock   sync.Mutex
 }
 
 // New created a empty JSON Printer and starts the printer ready for messages.
 func New(buffer uint) *JSONPrinter {
+	jp := newPrinter(buffer)
+	go jp.printlogs()
+	return jp
+}
+
+// newPrinter makes a JSON Printer without starting it.
+func newPrinter(buffer uint) *JSONPrinter {
 	jp := &JSONPrinter{
-		logsToPrint:  make(chan string, buffer),
+		logsToPrint:  make(chan shared.Entry, buffer),
 		FinishedChan: make(chan bool, 1),
-		decorations:  make([]map[string]interface{}, 0),
 	}
-	go jp.printlogs()
+	jp.ctx, jp.cancel = context.WithCancel(context.Background())
+	jp.config.Store(&config{
+		level:       shared.LevelInfo,
+		decorations: make([]map[string]interface{}, 0),
+	})
 	return jp
 }
 
 // EnableDebugLogging signals the Logger to print debug messages.
+// Turning it on sets the level to LevelDebug, turning it off raises the level to LevelInfo if
+// it is lower.
 func (j *JSONPrinter) EnableDebugLogging(toggle bool) {
-	j.printDebug = toggle
+	j.updateConfig(func(c *config) {
+		if toggle {
+			c.level = shared.LevelDebug
+			return
+		}
+		if c.level < shared.LevelInfo {
+			c.level = shared.LevelInfo
+		}
+	})
+}
+
+// SetLevel sets the lowest level of message that will be printed. The default is LevelInfo.
+func (j *JSONPrinter) SetLevel(level shared.Level) {
+	j.updateConfig(func(c *config) { c.level = level })
+}
+
+// Level returns the lowest level of message that will be printed.
+func (j *JSONPrinter) Level() shared.Level {
+	return j.loadConfig().level
+}
+
+// Enabled tells you if a message of the given level would be printed. Use it to avoid building
+// messages that would be thrown away.
+func (j *JSONPrinter) Enabled(level shared.Level) bool {
+	return level >= j.loadConfig().level
 }
 
 // EnablePrettyPrint signals the Logger to print human readable messages.
 func (j *JSONPrinter) EnablePrettyPrint(toggle bool) {
-	j.printPretty = toggle
+	j.updateConfig(func(c *config) { c.printPretty = toggle })
 }
 
 // EnableAuditMode will cause the logger to slow down if it us unable to process logs fast enough.
 // Consider using this with a high buffer count.
 func (j *JSONPrinter) EnableAuditMode(toggle bool) {
-	j.auditmode = toggle
+	j.updateConfig(func(c *config) { c.auditmode = toggle })
 }
 
 // EnableHumanTimestamps will instruct the printer to tell the JSONMessages that get passed in to try set
 // a human readable timestamp.
 func (j *JSONPrinter) EnableHumanTimestamps(toggle bool) {
-	j.humanTimestamps = toggle
+	j.updateConfig(func(c *config) { c.humanTimestamps = toggle })
+}
+
+// SetNesting sets what happens to nested objects and dotted keys when messages are written.
+// jsonmessage.FlattenNesting writes groups as dotted keys, jsonmessage.ExpandNesting writes
+// dotted keys as groups and jsonmessage.KeepNesting, the default, writes messages as they are.
+// Mutators see messages before the nesting is applied.
+func (j *JSONPrinter) SetNesting(nesting jsonmessage.Nesting) {
+	j.updateConfig(func(c *config) { c.nesting = nesting })
+}
+
+// SetEncoder sets how messages are written, such as jsonmessage.LogfmtEncoder{}. An encoder
+// takes the place of EnablePrettyPrint, set it back to nil to go back to plain or pretty JSON.
+func (j *JSONPrinter) SetEncoder(encoder jsonmessage.Encoder) {
+	j.updateConfig(func(c *config) { c.encoder = encoder })
+}
+
+// SetColourMode sets when messages are written as single coloured lines with
+// jsonmessage.ConsoleEncoder. The default, shared.ColourAuto, does so for messages printed to a
+// terminal unless NO_COLOR is set. An encoder set with SetEncoder or pretty printing take
+// precedence.
+func (j *JSONPrinter) SetColourMode(mode shared.ColourMode) {
+	j.updateConfig(func(c *config) { c.colour = mode })
 }
 
 func (j *JSONPrinter) printlogs() {
 	for {
 		select {
-		case msg, ok := <-j.logsToPrint:
+		case entry, ok := <-j.logsToPrint:
 			if !ok {
+				j.flushTransport()
 				j.FinishedChan <- true
+				close(j.FinishedChan)
 				return
 			}
-			if j.transportOverride != nil {
-				j.transportOverride.Send(msg)
-			} else {
-				j.defaultPrinter(msg)
+			if entry.Synced != nil {
+				close(entry.Synced)
+				continue
 			}
+			atomic.StoreInt32(&j.inFlight, 1)
+			j.print(entry)
+			entry.Message.Free()
+			atomic.StoreInt32(&j.inFlight, 0)
 		}
 	}
 }
 
-func (j JSONPrinter) defaultPrinter(msg string) {
-	fmt.Println(msg)
+// print hands the message to the current output and records how it went.
+// Transports can find the level of the message with overrides.LevelFromContext.
+func (j *JSONPrinter) print(entry shared.Entry) {
+	c := j.loadConfig()
+	length := entry.Message.Len()
+	switch {
+	case c.transport != nil:
+		ctx := overrides.ContextWithLevel(j.ctx, entry.Level)
+		if err := c.transport.Send(ctx, entry.Message.Bytes()); err != nil {
+			j.stats.TransportFailed()
+			return
+		}
+	case c.transportOverride != nil:
+		if override, ok := c.transportOverride.(overrides.LevelOverrider); ok {
+			override.SendLevel(entry.Level, entry.Message.String())
+			break
+		}
+		c.transportOverride.Send(entry.Message.String())
+	default:
+		j.defaultPrinter(entry.Message)
+	}
+	j.stats.Written(length)
+}
+
+// flushTransport lets the current output know that there are no more messages coming so
+// it should ship anything that it is holding on to.
+func (j *JSONPrinter) flushTransport() {
+	c := j.loadConfig()
+	var output interface{} = c.transportOverride
+	if c.transport != nil {
+		output = c.transport
+	}
+
+	if flusher, ok := output.(overrides.Flusher); ok {
+		if err := flusher.Flush(j.ctx); err != nil {
+			j.stats.TransportFailed()
+		}
+	}
+}
+
+// defaultPrinter writes the message and a new line to stdout in one go.
+func (j *JSONPrinter) defaultPrinter(msg *shared.Buffer) {
+	msg.WriteByte('\n')
+	os.Stdout.Write(msg.Bytes())
 }
 
 // OverridePrinter is used to insert your own function for hijacking the message on the
 // way to the console. This allows you to push the log message to where ever you want.
+// A printer has one output, so it replaces anything set with OverrideTransport. If both are
+// called the last one wins.
 func (j *JSONPrinter) OverridePrinter(override overrides.Overrider) {
-	j.transportOverride = override
+	j.updateConfig(func(c *config) {
+		c.transportOverride = override
+		c.transport = nil
+	})
+}
+
+// OverrideTransport is used like OverridePrinter but takes a Transport which is able to report
+// that it failed to ship a message. Failures are counted in the TransportFailures statistic.
+// It replaces anything set with OverridePrinter. If both are called the last one wins.
+func (j *JSONPrinter) OverrideTransport(transport overrides.Transport) {
+	j.updateConfig(func(c *config) {
+		c.transport = transport
+		c.transportOverride = nil
+	})
 }
 
 // Flush stops the logger from consuming more messages.
 // Flush returns a chan bool to tell you when all messages
 // have been printed. The channel will be closed once all messages have been flushed.
+// Flush can be called as many times as you like, every call returns the same channel.
 func (j *JSONPrinter) Flush() chan bool {
-	if j.shutdown {
-		c := make(chan bool, 1)
-		close(c)
-		return c
+	j.lifecycle.Shutdown(func() { close(j.logsToPrint) })
+	return j.FinishedChan
+}
+
+// Sync returns a channel that is closed once every message sent before the call has been
+// printed. Unlike Flush the printer keeps running afterwards.
+func (j *JSONPrinter) Sync() chan bool {
+	return shared.Sync(&j.lifecycle, j.logsToPrint, j.FinishedChan)
+}
+
+// FlushContext flushes the printer like Flush but will stop waiting when ctx expires. The report
+// tells you how many messages were left behind. If a flush fallback has been set the messages that
+// were still in the buffer are handed to it so they are not lost. The returned error is ctx.Err()
+// if the flush did not finish in time, in which case the context given to the Transport is cancelled.
+func (j *JSONPrinter) FlushContext(ctx context.Context) (shared.FlushReport, error) {
+	var fallback func(string)
+	if override := j.loadConfig().flushFallback; override != nil {
+		fallback = override.Send
 	}
 
-	close(j.logsToPrint)
-	j.shutdown = true
-	return j.FinishedChan
+	report, err := shared.WaitForFlush(
+		ctx,
+		j.Flush(),
+		j.logsToPrint,
+		func() int { return int(atomic.LoadInt32(&j.inFlight)) },
+		fallback,
+	)
+	// Let a stuck transport know that nobody is waiting for it any more.
+	if err != nil {
+		j.cancel()
+	}
+	return report, err
+}
+
+// SetFlushFallback sets where the messages left in the buffer go when FlushContext gives up
+// waiting. Something simple that can't get stuck like stderr is a good choice.
+func (j *JSONPrinter) SetFlushFallback(fallback overrides.Overrider) {
+	j.updateConfig(func(c *config) { c.flushFallback = fallback })
 }
 
 // AddDecoration is used to add default keys with corresponding values. These are added to ALL
 // JSONMessages that are sent via this printer.
+// The decoration is copied so changing the map afterwards will not change the printer.
 func (j *JSONPrinter) AddDecoration(decorator map[string]interface{}) {
-	j.decorations = append(j.decorations, decorator)
+	decoration := make(map[string]interface{}, len(decorator))
+	for key, value := range decorator {
+		decoration[key] = value
+	}
+
+	j.updateConfig(func(c *config) {
+		decorations := make([]map[string]interface{}, len(c.decorations), len(c.decorations)+1)
+		copy(decorations, c.decorations)
+		c.decorations = append(decorations, decoration)
+	})
 }
 
 // Send takes a pointer to a JSONMessage and send it to the printer.
-// If the logger is already shutdown then it will just silently consume the message.
+// Messages below the level of the printer are thrown away, the level is read from JSONLevelKey.
+// If the logger is already shutdown then it will just silently consume the message and count
+// it as dropped.
+// The message is encoded before Send returns so it can be changed or sent again afterwards.
 func (j *JSONPrinter) Send(msg *jsonmessage.JSONMessage) {
-	if j.shutdown {
-		return
-	}
+	c := j.loadConfig()
+	j.dispatch(nil, c, c.level, nil, 1, msg)
+}
 
-	j.decorate(msg)
-	if ok := j.runMutations(msg); !ok {
+// dispatch filters, decorates and mutates the message before sending it. Messages below
+// threshold are thrown away. ctx is nil unless the message came from SendContext. child is the
+// child printer that the message came from and is nil for messages from the printer itself.
+// depth is the number of frames between dispatch and the code that logged the message, 1 for
+// Send, and is used to find the caller.
+func (j *JSONPrinter) dispatch(ctx context.Context, c *config, threshold shared.Level, child *Child, depth int, msg *jsonmessage.JSONMessage) {
+	// Messages without a level, or with one that is not known, are treated as LevelInfo.
+	level, _ := msg.Level()
+	if j.skip(threshold, level) {
 		return
 	}
 
-	if msg.IsDebug() {
-		if !j.printDebug {
-			return
-		}
+	j.decorate(c, msg)
+	if ctx != nil {
+		addContextFields(ctx, c, msg)
 	}
-
-	if j.printPretty {
-		j.send(msg.PrettyString())
+	if child != nil {
+		child.decorate(msg)
+	}
+	annotate(c, level, depth, msg)
+	if ok := j.runMutations(c, msg); !ok {
+		j.stats.MutatorRejected()
 		return
 	}
+	c.nesting.Apply(msg)
 
-	j.send(msg.String())
+	buf := shared.GetBuffer()
+	switch {
+	case c.encoder != nil:
+		buf.Set(c.encoder.Encode(buf.Bytes(), msg))
+	case c.printPretty:
+		buf.Set(msg.AppendPrettyBytes(buf.Bytes()))
+	case c.colour.Enabled(c.transport == nil && c.transportOverride == nil):
+		buf.Set(jsonmessage.ConsoleEncoder{Colour: true}.Encode(buf.Bytes(), msg))
+	default:
+		buf.Set(msg.AppendBytes(buf.Bytes()))
+	}
+	j.send(c, level, buf)
 }
 
 // send will select the correct sending function for shipping logs.
-func (j *JSONPrinter) send(msg string) {
-	if j.auditmode {
-		shared.AuditSender(msg, j.logsToPrint)
+func (j *JSONPrinter) send(c *config, level shared.Level, msg *shared.Buffer) {
+	if !j.lifecycle.Enter() {
+		msg.Free()
+		j.stats.Dropped()
 		return
 	}
+	defer j.lifecycle.Leave()
 
-	shared.BestEffortSender(msg, j.logsToPrint, j.droppedMessage)
+	if c.auditmode {
+		shared.AuditEntrySender(shared.Entry{Level: level, Message: msg}, j.logsToPrint)
+		j.stats.Accepted()
+		return
+	}
+
+	if shared.BestEffortEntrySender(shared.Entry{Level: level, Message: msg}, j.logsToPrint, j.stats.Dropped) {
+		j.stats.Accepted()
+	}
 }
 
-func (j *JSONPrinter) droppedMessage() {
-	j.droppedMessages++
+// Stats returns a snapshot of what the printer has done with the messages it has been given.
+// It is safe to call from any goroutine.
+func (j *JSONPrinter) Stats() shared.Stats {
+	return j.stats.Snapshot(len(j.logsToPrint))
 }
 
-func (j *JSONPrinter) decorate(msg *jsonmessage.JSONMessage) {
+func (j *JSONPrinter) decorate(c *config, msg *jsonmessage.JSONMessage) {
 	// set human timestamps if needed.
-	if j.humanTimestamps {
+	if c.humanTimestamps {
 		msg.AddHumanTimestamp()
 	}
 	// Attach decorations
-	if len(j.decorations) > 0 {
-		for _, decoration := range j.decorations {
+	if len(c.decorations) > 0 {
+		for _, decoration := range c.decorations {
 			for key, value := range decoration {
 				msg.Add(key, value)
 			}
@@ -183,12 +411,16 @@ func (j *JSONPrinter) decorate(msg *jsonmessage.JSONMessage) {
 // was to know how to fix it. It is up to the developer to make sure that the message is not damaged
 // when the mutator is completed.
 func (j *JSONPrinter) AddMutator(m Mutator) {
-	j.mutatorList = append(j.mutatorList, m)
+	j.updateConfig(func(c *config) {
+		mutators := make([]Mutator, len(c.mutatorList), len(c.mutatorList)+1)
+		copy(mutators, c.mutatorList)
+		c.mutatorList = append(mutators, m)
+	})
 }
 
-func (j *JSONPrinter) runMutations(jm *jsonmessage.JSONMessage) bool {
-	if len(j.mutatorList) > 0 {
-		for _, mutator := range j.mutatorList {
+func (j *JSONPrinter) runMutations(c *config, jm *jsonmessage.JSONMessage) bool {
+	if len(c.mutatorList) > 0 {
+		for _, mutator := range c.mutatorList {
 			if ok := mutator.Mutate(jm); !ok {
 				return false
 			}
@@ -1,13 +1,21 @@
 package jsonprinter
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/silverstagtech/gotracer"
 	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/overrides"
+	"github.com/silverstagtech/loggos/shared"
 )
 
 func TestJSONPrinter(t *testing.T) {
@@ -226,3 +234,602 @@ func TestHumanTimestampping(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestStats(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.AddMutator(
+		&TestMutator{
+			mutator: func(jm *jsonmessage.JSONMessage) bool {
+				_, ok := jm.RawDump()["reject"]
+				return !ok
+			},
+		},
+	)
+
+	info := jsonmessage.New()
+	info.SetInfo()
+	info.Message("accepted")
+	jp.Send(info)
+
+	debug := jsonmessage.New()
+	debug.SetDebug()
+	debug.Message("filtered")
+	jp.Send(debug)
+
+	rejected := jsonmessage.New()
+	rejected.SetInfo()
+	rejected.Add("reject", true)
+	jp.Send(rejected)
+
+	<-jp.Flush()
+
+	stats := jp.Stats()
+	if stats.Accepted != 1 || stats.DebugFiltered != 1 || stats.MutatorRejected != 1 {
+		t.Logf("Stats did not count the messages correctly. Got: %+v", stats)
+		t.Fail()
+	}
+	if stats.BytesWritten != int64(len(tracing.Show()[0])) {
+		t.Logf("Stats has the wrong number of bytes written. Got: %d, Want: %d", stats.BytesWritten, len(tracing.Show()[0]))
+		t.Fail()
+	}
+}
+
+func TestStatsDropped(t *testing.T) {
+	// Nothing is reading from the buffer so the second message has nowhere to go.
+	jp := newPrinter(1)
+
+	for i := 0; i < 2; i++ {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jp.Send(jm)
+	}
+
+	stats := jp.Stats()
+	if stats.Dropped != 1 || stats.BufferDepth != 1 {
+		t.Logf("Expected 1 dropped message and a buffer depth of 1. Got: %+v", stats)
+		t.Fail()
+	}
+}
+
+// countingOverrider counts the messages it is given and is safe to use while the printer
+// is being reconfigured.
+type countingOverrider struct {
+	count int64
+}
+
+func (co *countingOverrider) Send(string) {
+	atomic.AddInt64(&co.count, 1)
+}
+
+func TestConcurrentConfiguration(t *testing.T) {
+	// This test is only meaningful when run with the race detector, go test -race.
+	jp := New(100)
+	jp.OverridePrinter(&countingOverrider{})
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				toggle := n%2 == 0
+				jp.EnableDebugLogging(toggle)
+				jp.EnablePrettyPrint(toggle)
+				jp.EnableAuditMode(toggle)
+				jp.EnableHumanTimestamps(toggle)
+				jp.AddDecoration(map[string]interface{}{fmt.Sprintf("key_%d", i): n})
+				jp.AddMutator(&TestMutator{mutator: func(*jsonmessage.JSONMessage) bool { return true }})
+				jp.OverridePrinter(&countingOverrider{})
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				jm := jsonmessage.New()
+				if n%2 == 0 {
+					jm.SetDebug()
+				} else {
+					jm.SetInfo()
+				}
+				jm.Message("concurrent message")
+				jp.Send(jm)
+			}
+		}()
+	}
+	wg.Wait()
+	<-jp.Flush()
+}
+
+func TestFlushTwice(t *testing.T) {
+	jp := New(10)
+	first := jp.Flush()
+	second := jp.Flush()
+	<-first
+	<-second
+
+	if first != second {
+		t.Logf("Flushing twice returned different channels.")
+		t.Fail()
+	}
+}
+
+func TestSendWhileFlushing(t *testing.T) {
+	for _, audit := range []bool{false, true} {
+		co := &countingOverrider{}
+		jp := New(5)
+		jp.EnableAuditMode(audit)
+		jp.OverridePrinter(co)
+
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for n := 0; n < 100; n++ {
+					jm := jsonmessage.New()
+					jm.SetInfo()
+					jm.Messagef("message %d", n)
+					jp.Send(jm)
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-jp.Flush()
+		}()
+		wg.Wait()
+		<-jp.Flush()
+
+		stats := jp.Stats()
+		if stats.Accepted+stats.Dropped != 400 {
+			t.Logf("Audit mode %t: messages were lost without being counted. Got: %+v", audit, stats)
+			t.Fail()
+		}
+		if atomic.LoadInt64(&co.count) != stats.Accepted {
+			t.Logf("Audit mode %t: accepted messages were not printed. Printed %d, Stats: %+v", audit, co.count, stats)
+			t.Fail()
+		}
+	}
+}
+
+// blockingOverrider stands in for an output that has got stuck. It holds on to every message
+// until release is closed.
+type blockingOverrider struct {
+	release chan bool
+}
+
+func (bo *blockingOverrider) Send(string) {
+	<-bo.release
+}
+
+func TestFlushContext(t *testing.T) {
+	stuck := &blockingOverrider{release: make(chan bool)}
+	defer close(stuck.release)
+	fallback := gotracer.New()
+
+	jp := New(10)
+	jp.OverridePrinter(stuck)
+	jp.SetFlushFallback(fallback)
+
+	for i := 0; i < 3; i++ {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jm.Messagef("message %d", i)
+		jp.Send(jm)
+	}
+
+	// Wait for the printer to get stuck on the first message.
+	for jp.Stats().BufferDepth != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	report, err := jp.FlushContext(ctx)
+
+	if err != context.DeadlineExceeded {
+		t.Logf("FlushContext did not give back the context error. Got: %v", err)
+		t.Fail()
+	}
+	if report.InFlight != 1 || report.Buffered != 2 || report.Recovered != 2 {
+		t.Logf("FlushContext reported the wrong left overs. Got: %+v", report)
+		t.Fail()
+	}
+	if fallback.Len() != 2 {
+		t.Logf("The fallback did not get the buffered messages. Got: %v", fallback.Show())
+		t.Fail()
+	}
+}
+
+func TestOverrideTransport(t *testing.T) {
+	fail := true
+	received := []string{}
+	transport := overrides.TransportFunc(func(_ context.Context, msg []byte) error {
+		if fail {
+			fail = false
+			return errors.New("transport failed")
+		}
+		received = append(received, string(msg))
+		return nil
+	})
+
+	jp := New(10)
+	jp.OverrideTransport(transport)
+	for i := 0; i < 2; i++ {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jp.Send(jm)
+	}
+	<-jp.Flush()
+
+	stats := jp.Stats()
+	if stats.TransportFailures != 1 || len(received) != 1 {
+		t.Logf("Transport failures were not counted. Stats: %+v, Received: %v", stats, received)
+		t.Fail()
+	}
+	if stats.BytesWritten != int64(len(received[0])) {
+		t.Logf("Failed messages should not count as written. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestOverridePrecedence(t *testing.T) {
+	for _, transportLast := range []bool{false, true} {
+		printer := gotracer.New()
+		received := 0
+		transport := overrides.TransportFunc(func(_ context.Context, msg []byte) error {
+			received++
+			return nil
+		})
+
+		jp := New(10)
+		if transportLast {
+			jp.OverridePrinter(printer)
+			jp.OverrideTransport(transport)
+		} else {
+			jp.OverrideTransport(transport)
+			jp.OverridePrinter(printer)
+		}
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jp.Send(jm)
+		<-jp.Flush()
+
+		if transportLast && (received != 1 || printer.Len() != 0) {
+			t.Logf("OverrideTransport called last did not win. Transport: %d, Printer: %v", received, printer.Show())
+			t.Fail()
+		}
+		if !transportLast && (received != 0 || printer.Len() != 1) {
+			t.Logf("OverridePrinter called last did not win. Transport: %d, Printer: %v", received, printer.Show())
+			t.Fail()
+		}
+	}
+}
+
+func TestTeeLevels(t *testing.T) {
+	everything := gotracer.New()
+	critical := gotracer.New()
+
+	jp := New(10)
+	jp.OverrideTransport(overrides.NewTee(
+		overrides.TeeOutput{Transport: overrides.FromOverrider(everything), MinLevel: shared.LevelInfo, Buffer: 10},
+		overrides.TeeOutput{Transport: overrides.FromOverrider(critical), MinLevel: shared.LevelCrit, Buffer: 10},
+	))
+
+	info := jsonmessage.New()
+	info.SetInfo()
+	jp.Send(info)
+
+	crit := jsonmessage.New()
+	crit.SetCrit()
+	jp.Send(crit)
+
+	// Flushing the printer flushes the tee.
+	<-jp.Flush()
+
+	if everything.Len() != 2 || critical.Len() != 1 {
+		t.Logf("Messages were not routed by level. Everything: %v, Critical: %v", everything.Show(), critical.Show())
+		t.Fail()
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.SetLevel(shared.LevelWarn)
+
+	for _, set := range []func(*jsonmessage.JSONMessage){
+		(*jsonmessage.JSONMessage).SetDebug,
+		(*jsonmessage.JSONMessage).SetInfo,
+		(*jsonmessage.JSONMessage).SetWarn,
+		(*jsonmessage.JSONMessage).SetCrit,
+	} {
+		jm := jsonmessage.New()
+		set(jm)
+		jp.Send(jm)
+	}
+	// No level is treated as INFO.
+	jp.Send(jsonmessage.New())
+	<-jp.Flush()
+
+	if tracing.Len() != 2 {
+		t.Logf("Wanted only WARN and CRIT messages. Got: %v", tracing.Show())
+		t.Fail()
+	}
+	if stats := jp.Stats(); stats.DebugFiltered != 1 || stats.LevelFiltered != 2 {
+		t.Logf("Filtered messages were not counted. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestEnableDebugLoggingKeepsLevel(t *testing.T) {
+	jp := New(10)
+	defer jp.Flush()
+
+	jp.SetLevel(shared.LevelCrit)
+	jp.EnableDebugLogging(false)
+	if jp.Level() != shared.LevelCrit {
+		t.Logf("Turning debug off lowered the level. Got: %s", jp.Level())
+		t.Fail()
+	}
+
+	jp.EnableDebugLogging(true)
+	if !jp.Enabled(shared.LevelDebug) {
+		t.Logf("Turning debug on did not enable debug messages.")
+		t.Fail()
+	}
+}
+
+func TestNesting(t *testing.T) {
+	tests := []struct {
+		name    string
+		nesting jsonmessage.Nesting
+		want    string
+	}{
+		{name: "keep", nesting: jsonmessage.KeepNesting, want: `"http":{"status":200},"region.name":"eu"`},
+		{name: "flatten", nesting: jsonmessage.FlattenNesting, want: `"http.status":200,"region.name":"eu"`},
+		{name: "expand", nesting: jsonmessage.ExpandNesting, want: `"http":{"status":200},"region":{"name":"eu"}`},
+	}
+
+	for _, test := range tests {
+		tracing := gotracer.New()
+		jp := New(10)
+		jp.OverridePrinter(tracing)
+		jp.SetNesting(test.nesting)
+		// Decorations are nested too.
+		jp.AddDecoration(map[string]interface{}{"region.name": "eu"})
+
+		jm := jsonmessage.New()
+		jm.AddPath("http.status", 200)
+		jp.Send(jm)
+		<-jp.Flush()
+
+		if tracing.Len() != 1 || !strings.Contains(tracing.Show()[0], test.want) {
+			t.Logf("%s: wanted %s. Got: %v", test.name, test.want, tracing.Show())
+			t.Fail()
+		}
+	}
+}
+
+// pathMutator adds value under path to messages that have a message key.
+type pathMutator struct {
+	path  string
+	value interface{}
+}
+
+func (m pathMutator) Mutate(jm *jsonmessage.JSONMessage) bool {
+	if jm.Has(jsonmessage.JSONMessageKey) {
+		jm.AddPath(m.path, m.value)
+	}
+	return true
+}
+
+func TestNestingLeavesDecorationsAlone(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.SetNesting(jsonmessage.ExpandNesting)
+	jp.AddDecoration(map[string]interface{}{"http": map[string]interface{}{"host": "a"}})
+	jp.AddMutator(pathMutator{path: "user.admin", value: true})
+	child := jp.With(map[string]interface{}{"user": map[string]interface{}{"id": 7}})
+
+	first := jsonmessage.New()
+	first.Add("http.status", 500)
+	first.Message("first")
+	child.Send(first)
+	child.Send(jsonmessage.New())
+	<-jp.Flush()
+
+	expected := []string{
+		`"http":{"host":"a","status":500},"log_message":"first","timestamp":"\d+","user":{"admin":true,"id":7}}`,
+		`"http":{"host":"a"},"timestamp":"\d+","user":{"id":7}}`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %v", len(expected), got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %s", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestSendKeepsMessage(t *testing.T) {
+	first := gotracer.New()
+	second := gotracer.New()
+	jp1 := New(10)
+	jp1.OverridePrinter(first)
+	jp2 := New(10)
+	jp2.OverridePrinter(second)
+	jp2.AddMutator(pathMutator{path: "printer", value: 2})
+
+	jm := jsonmessage.New()
+	jm.Message("hello")
+	jp1.Send(jm)
+	jp2.Send(jm)
+	<-jp1.Flush()
+	<-jp2.Flush()
+
+	if first.Len() != 1 || !strings.Contains(first.Show()[0], `"log_message":"hello"`) {
+		t.Logf("First printer got the wrong message. Got: %v", first.Show())
+		t.Fail()
+	}
+	if second.Len() != 1 || !strings.Contains(second.Show()[0], `"log_message":"hello"`) {
+		t.Logf("Second printer got the wrong message. Got: %v", second.Show())
+		t.Fail()
+	}
+	if !strings.Contains(jm.String(), `"log_message":"hello"`) {
+		t.Logf("Message was emptied by Send. Got: %s", jm.String())
+		t.Fail()
+	}
+}
+
+func TestWriter(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.AddDecoration(map[string]interface{}{"source": "writer"})
+
+	w := jp.Writer(shared.LevelError)
+	fmt.Fprint(w, "first line\n[WARN] second line")
+	w.Flush()
+	<-jp.Flush()
+
+	expected := []string{
+		`"level":"ERROR","log_message":"first line","source":"writer"`,
+		`"level":"ERROR","log_message":"\[WARN\] second line","source":"writer"`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %v", len(expected), got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %s", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestSetEncoder(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+	jp.EnablePrettyPrint(true)
+	jp.AddDecoration(map[string]interface{}{"service": "api"})
+
+	send := func() {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jm.Message("test message")
+		jm.AddPath("http.status", 200)
+		jp.Send(jm)
+	}
+
+	jp.SetEncoder(jsonmessage.LogfmtEncoder{})
+	send()
+	jp.SetEncoder(jsonmessage.EncoderFunc(func(dst []byte, msg *jsonmessage.JSONMessage) []byte {
+		msg.Range(func(key string, value interface{}) bool {
+			dst = append(dst, key...)
+			dst = append(dst, ';')
+			return true
+		})
+		return dst
+	}))
+	send()
+	// Going back to no encoder uses pretty printing again.
+	jp.SetEncoder(nil)
+	send()
+	<-jp.Flush()
+
+	expected := []string{
+		`^timestamp=\d+ level=INFO log_message="test message" http.status=200 service=api$`,
+		`^http;level;log_message;service;timestamp;$`,
+		`^\{\n\s+"http": \{\n`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %v", len(expected), got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %s", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestColourMode(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+
+	send := func() {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jm.Message("test message")
+		jp.Send(jm)
+	}
+
+	// Overrides are never written to the console automatically.
+	send()
+	jp.SetColourMode(shared.ColourAlways)
+	send()
+	// Encoders win over the colour mode.
+	jp.SetEncoder(jsonmessage.LogfmtEncoder{})
+	send()
+	<-jp.Flush()
+
+	expected := []string{
+		`^{"level":"INFO","log_message":"test message","timestamp":"\d+"}$`,
+		regexp.QuoteMeta(shared.LevelColour(shared.LevelInfo)+"INFO"+shared.ColourReset) + `  test message$`,
+		`^timestamp=\d+ level=INFO log_message="test message"$`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %v", len(expected), got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %q", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+type discardTransport struct{}
+
+func (discardTransport) Send(context.Context, []byte) error { return nil }
+
+func BenchmarkSend(b *testing.B) {
+	jp := New(1000)
+	jp.OverrideTransport(discardTransport{})
+	jp.EnableAuditMode(true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jm.Message("request finished")
+		jm.AddFields(jsonmessage.String("path", "/api/v1/users"), jsonmessage.Int64("status", 200))
+		jp.Send(jm)
+	}
+	<-jp.Flush()
+}
+
+func BenchmarkSendFiltered(b *testing.B) {
+	jp := New(1000)
+	jp.OverrideTransport(discardTransport{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jp.Debug("request finished", "path", "/api/v1/users")
+	}
+	<-jp.Flush()
+}
@@ -0,0 +1,125 @@
+package jsonprinter
+
+import (
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// Log builds a message at level from msg and alternating keys and values, then sends it.
+// Keys must be strings, values that can't be paired with a key are kept under
+// jsonmessage.JSONBadKey. Nothing is built if level is below the level of the printer.
+func (j *JSONPrinter) Log(level shared.Level, msg string, keyvals ...interface{}) {
+	j.log(1, level, msg, keyvals)
+}
+
+// Trace sends msg and keyvals at LevelTrace. See Log for how keyvals are used.
+func (j *JSONPrinter) Trace(msg string, keyvals ...interface{}) {
+	j.log(1, shared.LevelTrace, msg, keyvals)
+}
+
+// Debug sends msg and keyvals at LevelDebug. See Log for how keyvals are used.
+func (j *JSONPrinter) Debug(msg string, keyvals ...interface{}) {
+	j.log(1, shared.LevelDebug, msg, keyvals)
+}
+
+// Info sends msg and keyvals at LevelInfo. See Log for how keyvals are used.
+func (j *JSONPrinter) Info(msg string, keyvals ...interface{}) {
+	j.log(1, shared.LevelInfo, msg, keyvals)
+}
+
+// Warn sends msg and keyvals at LevelWarn. See Log for how keyvals are used.
+func (j *JSONPrinter) Warn(msg string, keyvals ...interface{}) {
+	j.log(1, shared.LevelWarn, msg, keyvals)
+}
+
+// Error sends msg and keyvals at LevelError. See Log for how keyvals are used.
+func (j *JSONPrinter) Error(msg string, keyvals ...interface{}) {
+	j.log(1, shared.LevelError, msg, keyvals)
+}
+
+// Crit sends msg and keyvals at LevelCrit. See Log for how keyvals are used.
+func (j *JSONPrinter) Crit(msg string, keyvals ...interface{}) {
+	j.log(1, shared.LevelCrit, msg, keyvals)
+}
+
+// Log builds a message at level from msg and alternating keys and values, then sends it like
+// Send. Nothing is built if level is below the level of the child.
+func (c *Child) Log(level shared.Level, msg string, keyvals ...interface{}) {
+	c.log(1, level, msg, keyvals)
+}
+
+// Trace sends msg and keyvals at LevelTrace. See Log for how keyvals are used.
+func (c *Child) Trace(msg string, keyvals ...interface{}) {
+	c.log(1, shared.LevelTrace, msg, keyvals)
+}
+
+// Debug sends msg and keyvals at LevelDebug. See Log for how keyvals are used.
+func (c *Child) Debug(msg string, keyvals ...interface{}) {
+	c.log(1, shared.LevelDebug, msg, keyvals)
+}
+
+// Info sends msg and keyvals at LevelInfo. See Log for how keyvals are used.
+func (c *Child) Info(msg string, keyvals ...interface{}) {
+	c.log(1, shared.LevelInfo, msg, keyvals)
+}
+
+// Warn sends msg and keyvals at LevelWarn. See Log for how keyvals are used.
+func (c *Child) Warn(msg string, keyvals ...interface{}) {
+	c.log(1, shared.LevelWarn, msg, keyvals)
+}
+
+// Error sends msg and keyvals at LevelError. See Log for how keyvals are used.
+func (c *Child) Error(msg string, keyvals ...interface{}) {
+	c.log(1, shared.LevelError, msg, keyvals)
+}
+
+// Crit sends msg and keyvals at LevelCrit. See Log for how keyvals are used.
+func (c *Child) Crit(msg string, keyvals ...interface{}) {
+	c.log(1, shared.LevelCrit, msg, keyvals)
+}
+
+// log builds and sends a structured message. depth is the number of frames between log and the
+// code that logged the message, 1 for the structured functions that call log directly.
+func (j *JSONPrinter) log(depth int, level shared.Level, msg string, keyvals []interface{}) {
+	c := j.loadConfig()
+	if j.skip(c.level, level) {
+		return
+	}
+	jm := newStructured(level, msg, keyvals)
+	j.dispatch(nil, c, c.level, nil, 1+depth, jm)
+	jm.Release()
+}
+
+// log is the child version of JSONPrinter.log.
+func (c *Child) log(depth int, level shared.Level, msg string, keyvals []interface{}) {
+	threshold := c.Level()
+	if c.printer.skip(threshold, level) {
+		return
+	}
+	jm := newStructured(level, msg, keyvals)
+	c.printer.dispatch(nil, c.printer.loadConfig(), threshold, c, 1+depth, jm)
+	jm.Release()
+}
+
+// skip tells the structured functions to not bother building a message if it is below threshold
+// or the printer has been flushed. Messages skipped here are counted as filtered or dropped.
+func (j *JSONPrinter) skip(threshold, level shared.Level) bool {
+	if j.lifecycle.IsShutdown() {
+		j.stats.Dropped()
+		return true
+	}
+	if level < threshold {
+		j.stats.Filtered(level)
+		return true
+	}
+	return false
+}
+
+// newStructured builds a message from the pool, the caller releases it once it is dispatched.
+func newStructured(level shared.Level, msg string, keyvals []interface{}) *jsonmessage.JSONMessage {
+	jm := jsonmessage.Acquire()
+	jm.SetLevel(level)
+	jm.Message(msg)
+	jm.AddPairs(keyvals...)
+	return jm
+}
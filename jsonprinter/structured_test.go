@@ -0,0 +1,80 @@
+package jsonprinter
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+type countingStamper struct {
+	count int
+}
+
+func (s *countingStamper) Stamp() string {
+	s.count++
+	return "0"
+}
+
+func TestStructured(t *testing.T) {
+	tracing := gotracer.New()
+	jp := New(10)
+	jp.OverridePrinter(tracing)
+
+	jp.Info("Test structured message.", "user", 7, "admin", true)
+	jp.Named("db").Warn("Test structured message.", "table", "users", 42)
+	<-jp.Flush()
+
+	if tracing.Len() != 2 {
+		t.Fatalf("Wanted 2 messages. Got: %v", tracing.Show())
+	}
+
+	expected := []map[string]interface{}{
+		{jsonmessage.JSONLevelKey: "INFO", jsonmessage.JSONMessageKey: "Test structured message.", "user": float64(7), "admin": true},
+		{jsonmessage.JSONLevelKey: "WARN", jsonmessage.JSONLoggerKey: "db", "table": "users", jsonmessage.JSONBadKey: "[42]"},
+	}
+	for i, want := range expected {
+		out := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(tracing.Show()[i]), &out); err != nil {
+			t.Fatalf("Message is not JSON. Error: %s", err)
+		}
+		for key, value := range want {
+			got := out[key]
+			if _, ok := value.(string); ok {
+				got = fmt.Sprint(got)
+			}
+			if got != value {
+				t.Logf("Message %d has %s set to %v, wanted %v.", i, key, out[key], value)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestStructuredSkipsFiltered(t *testing.T) {
+	stamper := &countingStamper{}
+	jsonmessage.JSONTimeStampFunc = stamper
+	defer func() { jsonmessage.JSONTimeStampFunc = nil }()
+
+	jp := New(10)
+	jp.OverridePrinter(gotracer.New())
+	jp.Debug("Test filtered message.", "user", 7)
+	jp.Named("db").Trace("Test filtered message.")
+	<-jp.Flush()
+
+	if stamper.count != 0 {
+		t.Logf("Filtered messages were built %d times.", stamper.count)
+		t.Fail()
+	}
+	if stats := jp.Stats(); stats.DebugFiltered != 1 || stats.LevelFiltered != 1 {
+		t.Logf("Filtered messages were not counted. Stats: %+v", stats)
+		t.Fail()
+	}
+	if jp.Enabled(shared.LevelDebug) {
+		t.Logf("Debug should not be enabled by default.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,12 @@
+package jsonprinter
+
+import "github.com/silverstagtech/loggos/shared"
+
+// Writer returns an io.Writer that sends each line written to it as a message at level. Use it
+// to catch the output of libraries that write to an io.Writer or a *log.Logger. Call
+// DetectLevels on it to pick up levels like [WARN] at the start of lines.
+func (j *JSONPrinter) Writer(level shared.Level) *shared.LineWriter {
+	return shared.NewLineWriter(level, func(level shared.Level, line string) {
+		j.log(1, level, line, nil)
+	})
+}
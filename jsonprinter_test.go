@@ -1,6 +1,8 @@
 package loggos
 
 import (
+	"context"
+	"regexp"
 	"testing"
 
 	"github.com/silverstagtech/gotracer"
@@ -80,3 +82,70 @@ func TestJSONSendPanic(t *testing.T) {
 	sendOnAllJSONFunctions(true, t)
 	shutdownCurrentLoggers()
 }
+
+func TestStats(t *testing.T) {
+	JSONLoggerEnableDebugLogging(false)
+	DefaultJSONLogger.OverridePrinter(gotracer.New())
+	LineLoggerEnableDebugLogging(false)
+	DefaultLineLogger.OverridePrinter(gotracer.New())
+
+	SendJSON(JSONInfoln("Test Message - JSONInfoln"))
+	SendJSON(JSONDebugln("Test Message - JSONDebugln"))
+	Infoln("Test Message - Infoln")
+	Debugln("Test Message - Debugln")
+	<-Flush()
+
+	stats := Stats()
+	if stats.Accepted != 2 || stats.DebugFiltered != 2 {
+		t.Logf("Package stats did not merge the default loggers. Got: %+v", stats)
+		t.Fail()
+	}
+	// The default loggers are already flushed, flushing them again must be safe.
+	shutdownCurrentLoggers()
+}
+
+func TestFlushContext(t *testing.T) {
+	tracing := gotracer.New()
+	JSONLoggerEnableDebugLogging(false)
+	DefaultJSONLogger.OverridePrinter(tracing)
+	LineLoggerEnableDebugLogging(false)
+	DefaultLineLogger.OverridePrinter(gotracer.New())
+	Infoln("Test Message - Infoln")
+	SendJSON(JSONInfoln("Test Message - JSONInfoln"))
+
+	report, err := FlushContext(context.Background())
+	if err != nil || report.Unflushed() != 0 || tracing.Len() != 1 {
+		t.Logf("FlushContext did not flush the default loggers. Report: %+v, Error: %v", report, err)
+		t.Fail()
+	}
+	shutdownCurrentLoggers()
+}
+
+func TestStructuredShortcuts(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+
+	tracing := gotracer.New()
+	startdefaultJSONLogger()
+	DefaultJSONLogger.OverridePrinter(tracing)
+
+	Info("Test Message - Info", "user", 7)
+	Error("Test Message - Error", "odd")
+	Debug("Test Message - Debug", "user", 7)
+	<-Flush()
+
+	expected := []string{
+		`"user":7`,
+		`"!BADKEY":\["odd"\]`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %v", len(expected), got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %s", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
@@ -1,5 +1,15 @@
 package loggos
 
+import (
+	"fmt"
+	"os"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// exit is used by the Fatal shortcuts, tests replace it.
+var exit = os.Exit
+
 // The below functions are all shortcuts that relate to the default logger.
 // Look at the function comments for the Line Logger type for details on what they do.
 
@@ -8,47 +18,110 @@ func LineLoggerEnableDebugLogging(toggle bool) {
 	DefaultLineLogger.EnableDebugLogging(toggle)
 }
 
+func LineLoggerSetLevel(level shared.Level) {
+	startdefaultLineLogger()
+	DefaultLineLogger.SetLevel(level)
+}
+
 func Infoln(msg ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Infoln(msg...)
+	DefaultLineLogger.LogDepth(1, shared.LevelInfo, msg...)
 
 }
 func Warnln(msg ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Warnln(msg...)
+	DefaultLineLogger.LogDepth(1, shared.LevelWarn, msg...)
 
 }
 func Critln(msg ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Critln(msg...)
+	DefaultLineLogger.LogDepth(1, shared.LevelCrit, msg...)
 
 }
 func Debugln(msg ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Debugln(msg...)
+	DefaultLineLogger.LogDepth(1, shared.LevelDebug, msg...)
 
 }
 func Infof(format string, vars ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Infof(format, vars...)
+	DefaultLineLogger.LogfDepth(1, shared.LevelInfo, format, vars...)
 }
 func Warnf(format string, vars ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Warnf(format, vars...)
+	DefaultLineLogger.LogfDepth(1, shared.LevelWarn, format, vars...)
 }
 func Critf(format string, vars ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Critf(format, vars...)
+	DefaultLineLogger.LogfDepth(1, shared.LevelCrit, format, vars...)
 }
 func Debugf(format string, vars ...interface{}) {
 	// Start the line logger if needed.
 	startdefaultLineLogger()
-	DefaultLineLogger.Debugf(format, vars...)
+	DefaultLineLogger.LogfDepth(1, shared.LevelDebug, format, vars...)
+}
+func Traceln(msg ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogDepth(1, shared.LevelTrace, msg...)
+}
+func Errorln(msg ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogDepth(1, shared.LevelError, msg...)
+}
+func Tracef(format string, vars ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogfDepth(1, shared.LevelTrace, format, vars...)
+}
+func Errorf(format string, vars ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogfDepth(1, shared.LevelError, format, vars...)
+}
+
+// Fatalln logs the message at FATAL, flushes all the default loggers then exits with status 1.
+func Fatalln(msg ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogDepth(1, shared.LevelFatal, msg...)
+	<-Flush()
+	exit(1)
+}
+
+// Fatalf logs the message at FATAL, flushes all the default loggers then exits with status 1.
+func Fatalf(format string, vars ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogfDepth(1, shared.LevelFatal, format, vars...)
+	<-Flush()
+	exit(1)
+}
+
+// Panicln logs the message at PANIC, waits for the default loggers to print it then panics with
+// the message. The loggers keep running in case the panic is recovered.
+func Panicln(msg ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogDepth(1, shared.LevelPanic, msg...)
+	<-Sync()
+	panic(fmt.Sprintln(msg...))
+}
+
+// Panicf logs the message at PANIC, waits for the default loggers to print it then panics with
+// the message. The loggers keep running in case the panic is recovered.
+func Panicf(format string, vars ...interface{}) {
+	// Start the line logger if needed.
+	startdefaultLineLogger()
+	DefaultLineLogger.LogfDepth(1, shared.LevelPanic, format, vars...)
+	<-Sync()
+	panic(fmt.Sprintf(format, vars...))
 }
@@ -0,0 +1,63 @@
+package lineprinter
+
+import "github.com/silverstagtech/loggos/shared"
+
+// EnableCaller makes the logger end each message with where it was logged from, written as
+// caller=server/handler.go:42 function=server.(*Handler).ServeHTTP.
+func (l *Logger) EnableCaller(toggle bool) {
+	l.updateConfig(func(c *config) { c.caller = toggle })
+}
+
+// SetCallerSkip sets how many extra frames to skip when finding the caller and the stack trace.
+// Use it when the logger is called through your own logging functions so that the caller is
+// the code that called them rather than the functions themselves. It applies to every message,
+// use LogDepth and LogfDepth to skip frames for some calls only.
+func (l *Logger) SetCallerSkip(skip int) {
+	l.updateConfig(func(c *config) { c.callerSkip = skip })
+}
+
+// LogDepth is like Logln but skips depth more frames when finding the caller and the stack
+// trace. Use it from your own logging functions, a depth of 1 reports the code that called them.
+func (l *Logger) LogDepth(depth int, level shared.Level, msg ...interface{}) {
+	l.logln(1+depth, l.Level(), "", level, msg)
+}
+
+// LogfDepth is like Logf but skips depth more frames when finding the caller and the stack
+// trace, see LogDepth.
+func (l *Logger) LogfDepth(depth int, level shared.Level, format string, vars ...interface{}) {
+	l.logf(1+depth, l.Level(), "", level, format, vars)
+}
+
+// EnableStacktraces makes the logger write a stack trace on the lines after messages at level
+// or above.
+func (l *Logger) EnableStacktraces(level shared.Level) {
+	l.updateConfig(func(c *config) {
+		c.stacktraces = true
+		c.stacktraceLevel = level
+	})
+}
+
+// DisableStacktraces stops the logger writing stack traces.
+func (l *Logger) DisableStacktraces() {
+	l.updateConfig(func(c *config) { c.stacktraces = false })
+}
+
+// annotate writes the caller and stack trace to buf if the logger has been asked for them.
+// It must be called straight from logln or logf. depth is the number of frames between logln or
+// logf and the code that logged the message.
+func annotate(c *config, level shared.Level, depth int, buf *shared.Buffer) {
+	// Skip annotate and logln or logf as well.
+	skip := 2 + depth + c.callerSkip
+	if c.caller {
+		if caller, ok := shared.CallerAt(skip); ok {
+			buf.WriteString(" caller=")
+			buf.WriteString(caller.String())
+			buf.WriteString(" function=")
+			buf.WriteString(caller.Function)
+		}
+	}
+	if c.stacktraces && level >= c.stacktraceLevel {
+		buf.WriteByte('\n')
+		buf.WriteString(shared.Stacktrace(skip))
+	}
+}
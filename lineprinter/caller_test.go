@@ -0,0 +1,81 @@
+package lineprinter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+func TestCaller(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.EnableCaller(true)
+	child := logger.Named("child")
+
+	// Every way in to the logger must report this file and the line it was called from.
+	lines := []int{}
+	here := func() int {
+		caller, _ := shared.CallerAt(1)
+		return caller.Line + 1
+	}
+	lines = append(lines, here())
+	logger.Infoln("test message")
+	lines = append(lines, here())
+	logger.Warnf("test %s", "message")
+	lines = append(lines, here())
+	logger.Logln(shared.LevelCrit, "test message")
+	lines = append(lines, here())
+	child.Errorf("test %s", "message")
+	lines = append(lines, here())
+	child.Logf(shared.LevelInfo, "test %s", "message")
+	<-logger.Flush()
+
+	got := tracing.Show()
+	if len(got) != len(lines) {
+		t.Fatalf("Wanted %d messages. Got: %v", len(lines), got)
+	}
+	for i, line := range lines {
+		matcher := fmt.Sprintf(`test message caller=lineprinter/caller_test.go:%d function=lineprinter.TestCaller\n?$`, line)
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %q", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestStacktraces(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.EnableStacktraces(shared.LevelError)
+
+	logger.Warnln("no trace")
+	logger.Errorln("trace")
+	logger.Critf("trace")
+	<-logger.Flush()
+
+	got := tracing.Show()
+	if len(got) != 3 {
+		t.Fatalf("Wanted 3 messages. Got: %v", got)
+	}
+	// Warnln ends with a single new line, anything more is a stack trace.
+	if strings.Count(got[0], "\n") != 1 {
+		t.Logf("Message below the stack trace level has a stack trace. Got: %q", got[0])
+		t.Fail()
+	}
+	expected := []string{
+		`(?s)ERROR trace\ngithub.com/silverstagtech/loggos/lineprinter.TestStacktraces\n\t.*\n$`,
+		`(?s)CRIT trace\ngithub.com/silverstagtech/loggos/lineprinter.TestStacktraces\n\t.*[^\n]$`,
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i+1]) {
+			t.Logf("Message %d does not match %s. Got: %q", i+1, matcher, got[i+1])
+			t.Fail()
+		}
+	}
+}
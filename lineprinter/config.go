@@ -0,0 +1,43 @@
+package lineprinter
+
+import (
+	"github.com/silverstagtech/loggos/overrides"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// config holds the settings of a Logger. A stored config is never changed, setters take
+// a copy, change the copy and then store it. This lets the logging functions and the printing
+// goroutine read the settings without taking a lock.
+type config struct {
+	level             shared.Level
+	auditmode         bool
+	transportOverride overrides.Overrider
+	transport         overrides.Transport
+	flushFallback     overrides.Overrider
+	timestampFunc     func() string
+	caller            bool
+	callerSkip        int
+	stacktraces       bool
+	stacktraceLevel   shared.Level
+	colour            shared.ColourMode
+	format            *lineFormat
+	tagText           map[shared.Level]string
+	tagPadding        int
+	tags              map[shared.Level]string
+}
+
+// loadConfig returns the current settings. The returned config must not be changed.
+func (l *Logger) loadConfig() *config {
+	return l.config.Load().(*config)
+}
+
+// updateConfig copies the current settings, passes the copy to change and then stores it.
+// Setters are serialised so that two of them can't lose each others changes.
+func (l *Logger) updateConfig(change func(*config)) {
+	l.configLock.Lock()
+	defer l.configLock.Unlock()
+
+	c := *l.loadConfig()
+	change(&c)
+	l.config.Store(&c)
+}
@@ -0,0 +1,199 @@
+package lineprinter
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+type segmentKind uint8
+
+const (
+	literalSegment segmentKind = iota
+	timeSegment
+	levelSegment
+	nameSegment
+)
+
+// segment is a piece of a compiled format, either literal text or a value filled in for each message.
+type segment struct {
+	kind segmentKind
+	text string
+}
+
+// lineFormat is a compiled format template. before is written ahead of the message and after
+// behind it.
+type lineFormat struct {
+	before []segment
+	after  []segment
+}
+
+// SetFormat sets the template that lines are written with. The template is text with
+// placeholders in braces:
+//
+//	{time}  the timestamp from the timestamp function
+//	{level} the level tag, see SetLevelTags and SetLevelPadding
+//	{name}  the name of the child logger, empty for the logger itself
+//	{pid}   the process ID
+//	{msg}   the message, which must appear exactly once
+//
+// Use {{ and }} for literal braces. For example "{time} [{level}] billing {pid}: {msg}". The
+// template is compiled here so an error is returned for unknown placeholders. An empty template
+// goes back to the default format.
+func (l *Logger) SetFormat(template string) error {
+	if template == "" {
+		l.updateConfig(func(c *config) { c.format = nil })
+		return nil
+	}
+	format, err := compileFormat(template)
+	if err != nil {
+		return err
+	}
+	l.updateConfig(func(c *config) { c.format = format })
+	return nil
+}
+
+// SetLevelTags replaces the text written for the given levels, such as
+// map[shared.Level]string{shared.LevelWarn: "WARNING"}. Levels that are not in tags keep their
+// current text.
+func (l *Logger) SetLevelTags(tags map[shared.Level]string) {
+	l.updateConfig(func(c *config) {
+		text := make(map[shared.Level]string, len(c.tagText)+len(tags))
+		for level, tag := range c.tagText {
+			text[level] = tag
+		}
+		for level, tag := range tags {
+			text[level] = tag
+		}
+		c.tagText = text
+		c.tags = buildTags(c.tagText, c.tagPadding)
+	})
+}
+
+// SetLevelPadding pads level tags with spaces to width characters so that the messages after
+// them line up.
+func (l *Logger) SetLevelPadding(width int) {
+	l.updateConfig(func(c *config) {
+		c.tagPadding = width
+		c.tags = buildTags(c.tagText, c.tagPadding)
+	})
+}
+
+// buildTags works out the finished tag of every level once so that logging only has to look it up.
+func buildTags(text map[shared.Level]string, padding int) map[shared.Level]string {
+	tags := make(map[shared.Level]string, shared.LevelPanic+1)
+	for level := shared.LevelTrace; level <= shared.LevelPanic; level++ {
+		tag, ok := text[level]
+		if !ok {
+			tag = level.String()
+		}
+		if n := padding - len(tag); n > 0 {
+			tag += strings.Repeat(" ", n)
+		}
+		tags[level] = tag
+	}
+	return tags
+}
+
+// tag returns the text written for level.
+func (c *config) tag(level shared.Level) string {
+	if tag, ok := c.tags[level]; ok {
+		return tag
+	}
+	return level.String()
+}
+
+// compileFormat turns a template into segments. The process ID can't change so it is turned
+// into literal text here.
+func compileFormat(template string) (*lineFormat, error) {
+	format := &lineFormat{}
+	segments := &format.before
+	foundMsg := false
+	literal := strings.Builder{}
+
+	flush := func() {
+		if literal.Len() > 0 {
+			*segments = append(*segments, segment{kind: literalSegment, text: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "{{"), strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte(template[i])
+			i++
+		case template[i] == '}':
+			return nil, fmt.Errorf("unmatched } at %d in line format %q", i, template)
+		case template[i] == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed { at %d in line format %q", i, template)
+			}
+			placeholder := template[i+1 : i+end]
+			switch placeholder {
+			case "time":
+				flush()
+				*segments = append(*segments, segment{kind: timeSegment})
+			case "level":
+				flush()
+				*segments = append(*segments, segment{kind: levelSegment})
+			case "name":
+				flush()
+				*segments = append(*segments, segment{kind: nameSegment})
+			case "pid":
+				literal.WriteString(strconv.Itoa(os.Getpid()))
+			case "msg":
+				if foundMsg {
+					return nil, fmt.Errorf("{msg} is used more than once in line format %q", template)
+				}
+				foundMsg = true
+				flush()
+				segments = &format.after
+			default:
+				return nil, fmt.Errorf("unknown placeholder {%s} in line format %q", placeholder, template)
+			}
+			i += end
+		default:
+			literal.WriteByte(template[i])
+		}
+	}
+	flush()
+
+	if !foundMsg {
+		return nil, fmt.Errorf("{msg} is missing from line format %q", template)
+	}
+	return format, nil
+}
+
+// writeSegments writes segments to buf for a message at level from the child called name.
+func writeSegments(c *config, buf *shared.Buffer, segments []segment, level shared.Level, name string) {
+	colour := c.colour.Enabled(c.transport == nil && c.transportOverride == nil)
+	for _, s := range segments {
+		switch s.kind {
+		case literalSegment:
+			buf.WriteString(s.text)
+		case timeSegment:
+			if colour {
+				buf.WriteString(shared.ColourDim)
+			}
+			buf.WriteString(c.timestampFunc())
+			if colour {
+				buf.WriteString(shared.ColourReset)
+			}
+		case levelSegment:
+			if colour {
+				buf.WriteString(shared.LevelColour(level))
+			}
+			buf.WriteString(c.tag(level))
+			if colour {
+				buf.WriteString(shared.ColourReset)
+			}
+		case nameSegment:
+			buf.WriteString(name)
+		}
+	}
+}
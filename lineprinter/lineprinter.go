@@ -1,7 +1,11 @@
 package lineprinter
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/silverstagtech/loggos/overrides"
@@ -9,6 +13,8 @@ import (
 )
 
 var (
+	// exit is used by the Fatal functions, tests replace it.
+	exit = os.Exit
 	// DefaultLineTimeStampFunc is a time format override function for the line logger. Messages will have these prepended as soon as they arrive.
 	DefaultLineTimeStampFunc func() string
 )
@@ -23,12 +29,40 @@ type StandardLineLogger interface {
 	Infof(string, ...interface{})
 	Warnln(...interface{})
 	Warnf(string, ...interface{})
+	Errorln(...interface{})
+	Errorf(string, ...interface{})
 	Critln(...interface{})
 	Critf(string, ...interface{})
+	Fatalln(...interface{})
+	Fatalf(string, ...interface{})
+	Panicln(...interface{})
+	Panicf(string, ...interface{})
+	Logln(shared.Level, ...interface{})
+	Logf(shared.Level, string, ...interface{})
+	LogDepth(int, shared.Level, ...interface{})
+	LogfDepth(int, shared.Level, string, ...interface{})
 	Flush() chan bool
+	Sync() chan bool
+	FlushContext(context.Context) (shared.FlushReport, error)
+	SetFlushFallback(overrides.Overrider)
 	OverrideTimeStamping(func() string)
 	OverridePrinter(overrides.Overrider)
+	OverrideTransport(overrides.Transport)
 	EnableAuditMode(bool)
+	SetLevel(shared.Level)
+	Level() shared.Level
+	Enabled(shared.Level) bool
+	Stats() shared.Stats
+	Named(string) *Child
+	EnableCaller(bool)
+	SetCallerSkip(int)
+	EnableStacktraces(shared.Level)
+	DisableStacktraces()
+	Writer(shared.Level) *shared.LineWriter
+	SetColourMode(shared.ColourMode)
+	SetFormat(string) error
+	SetLevelTags(map[shared.Level]string)
+	SetLevelPadding(int)
 }
 
 // DebugLineLogger uses StandardLogger but also includes Debugging logs.
@@ -36,200 +70,461 @@ type DebugLineLogger interface {
 	StandardLineLogger
 	Debugln(...interface{})
 	Debugf(string, ...interface{})
+	Traceln(...interface{})
+	Tracef(string, ...interface{})
 	EnableDebugLogging(bool)
 }
 
 // Logger collects logs and prints them to the console in the order that it gets them.
 // It needs to be flushed when the user if finished with to to not loose any logs.
 type Logger struct {
-	printDebug        bool
-	logsToPrint       chan string
-	FinishedChan      chan bool
-	shutdown          bool
-	transportOverride overrides.Overrider
-	auditmode         bool
-	droppedMessages   int64
-	timestampFunc     func() string
+	// stats is kept first so that its counters are 64 bit aligned for atomic access.
+	stats        shared.Counters
+	lifecycle    shared.Lifecycle
+	inFlight     int32
+	ctx          context.Context
+	cancel       context.CancelFunc
+	logsToPrint  chan shared.Entry
+	FinishedChan chan bool
+	config       atomic.Value
+	configLock   sync.Mutex
 }
 
 // New creates a logger and returns it.
 func New(buffer uint) *Logger {
+	l := newLogger(buffer)
+	go l.printlogs()
+	return l
+}
+
+// newLogger makes a logger without starting it.
+func newLogger(buffer uint) *Logger {
 	l := &Logger{
-		logsToPrint:   make(chan string, buffer),
-		FinishedChan:  make(chan bool, 1),
-		timestampFunc: DefaultLineTimeStampFunc,
+		logsToPrint:  make(chan shared.Entry, buffer),
+		FinishedChan: make(chan bool, 1),
 	}
-	go l.printlogs()
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	l.config.Store(&config{
+		level:         shared.LevelInfo,
+		timestampFunc: DefaultLineTimeStampFunc,
+	})
 	return l
 }
 
 // OverrideTimeStamping is used to change the default timestamp on individual loggers.
 func (l *Logger) OverrideTimeStamping(f func() string) {
-	l.timestampFunc = f
+	l.updateConfig(func(c *config) { c.timestampFunc = f })
 }
 
 // OverridePrinter is used to insert your own function for hijacking the message on the
 // way to the console. This allows you to push the log message to where ever you want.
+// A printer has one output, so it replaces anything set with OverrideTransport. If both are
+// called the last one wins.
 func (l *Logger) OverridePrinter(override overrides.Overrider) {
-	l.transportOverride = override
+	l.updateConfig(func(c *config) {
+		c.transportOverride = override
+		c.transport = nil
+	})
+}
+
+// OverrideTransport is used like OverridePrinter but takes a Transport which is able to report
+// that it failed to ship a message. Failures are counted in the TransportFailures statistic.
+// It replaces anything set with OverridePrinter. If both are called the last one wins.
+func (l *Logger) OverrideTransport(transport overrides.Transport) {
+	l.updateConfig(func(c *config) {
+		c.transport = transport
+		c.transportOverride = nil
+	})
 }
 
 // EnableDebugLogging signals the Logger to print debug messages.
+// Turning it on sets the level to LevelDebug, turning it off raises the level to LevelInfo if
+// it is lower.
 func (l *Logger) EnableDebugLogging(toggle bool) {
-	l.printDebug = toggle
+	l.updateConfig(func(c *config) {
+		if toggle {
+			c.level = shared.LevelDebug
+			return
+		}
+		if c.level < shared.LevelInfo {
+			c.level = shared.LevelInfo
+		}
+	})
+}
+
+// SetLevel sets the lowest level of message that will be printed. The default is LevelInfo.
+func (l *Logger) SetLevel(level shared.Level) {
+	l.updateConfig(func(c *config) { c.level = level })
+}
+
+// Level returns the lowest level of message that will be printed.
+func (l *Logger) Level() shared.Level {
+	return l.loadConfig().level
+}
+
+// Enabled tells you if a message of the given level would be printed. Use it to avoid building
+// messages that would be thrown away.
+func (l *Logger) Enabled(level shared.Level) bool {
+	return level >= l.loadConfig().level
 }
 
 // EnableAuditMode will cause the logger to slow down if it us unable to process logs fast enough.
 // Consider using this with a high buffer count.
 func (l *Logger) EnableAuditMode(toggle bool) {
-	l.auditmode = toggle
+	l.updateConfig(func(c *config) { c.auditmode = toggle })
 }
 
 func (l *Logger) printlogs() {
 	for {
 		select {
-		case msg, ok := <-l.logsToPrint:
+		case entry, ok := <-l.logsToPrint:
 			if !ok {
+				l.flushTransport()
 				l.FinishedChan <- true
+				close(l.FinishedChan)
 				return
 			}
-			if l.transportOverride != nil {
-				l.transportOverride.Send(msg)
-			} else {
-				l.defaultPrinter(msg)
+			if entry.Synced != nil {
+				close(entry.Synced)
+				continue
 			}
+			atomic.StoreInt32(&l.inFlight, 1)
+			l.print(entry)
+			entry.Message.Free()
+			atomic.StoreInt32(&l.inFlight, 0)
+		}
+	}
+}
+
+// print hands the message to the current output and records how it went.
+// Transports can find the level of the message with overrides.LevelFromContext.
+func (l *Logger) print(entry shared.Entry) {
+	c := l.loadConfig()
+	length := entry.Message.Len()
+	switch {
+	case c.transport != nil:
+		ctx := overrides.ContextWithLevel(l.ctx, entry.Level)
+		if err := c.transport.Send(ctx, entry.Message.Bytes()); err != nil {
+			l.stats.TransportFailed()
+			return
+		}
+	case c.transportOverride != nil:
+		if override, ok := c.transportOverride.(overrides.LevelOverrider); ok {
+			override.SendLevel(entry.Level, entry.Message.String())
+			break
+		}
+		c.transportOverride.Send(entry.Message.String())
+	default:
+		l.defaultPrinter(entry.Message)
+	}
+	l.stats.Written(length)
+}
+
+// flushTransport lets the current output know that there are no more messages coming so
+// it should ship anything that it is holding on to.
+func (l *Logger) flushTransport() {
+	c := l.loadConfig()
+	var output interface{} = c.transportOverride
+	if c.transport != nil {
+		output = c.transport
+	}
+
+	if flusher, ok := output.(overrides.Flusher); ok {
+		if err := flusher.Flush(l.ctx); err != nil {
+			l.stats.TransportFailed()
 		}
 	}
 }
 
-func (l *Logger) defaultPrinter(msg string) {
-	fmt.Println(msg)
+// defaultPrinter writes the message and a new line to stdout in one go.
+func (l *Logger) defaultPrinter(msg *shared.Buffer) {
+	msg.WriteByte('\n')
+	os.Stdout.Write(msg.Bytes())
 }
 
 // Flush stops the logger from consuming more messages.
 // Flush returns a chan bool to tell you when all messages
 // have been printed. The channel will be closed once all messages have been flushed.
+// Flush can be called as many times as you like, every call returns the same channel.
 func (l *Logger) Flush() chan bool {
-	l.shutdown = true
-	close(l.logsToPrint)
+	l.lifecycle.Shutdown(func() { close(l.logsToPrint) })
 	return l.FinishedChan
 }
 
-func (l *Logger) prepender(tag, msg string) string {
-	if len(msg) == 0 {
-		return fmt.Sprintf("%s %s", l.timestampFunc(), tag)
-	}
-	return fmt.Sprintf("%s %s %s", l.timestampFunc(), tag, msg)
+// Sync returns a channel that is closed once every message sent before the call has been
+// printed. Unlike Flush the logger keeps running afterwards.
+func (l *Logger) Sync() chan bool {
+	return shared.Sync(&l.lifecycle, l.logsToPrint, l.FinishedChan)
 }
 
-func (l *Logger) prependInfo(msg string) string {
-	return l.prepender(shared.InformationMessage, msg)
-}
+// FlushContext flushes the printer like Flush but will stop waiting when ctx expires. The report
+// tells you how many messages were left behind. If a flush fallback has been set the messages that
+// were still in the buffer are handed to it so they are not lost. The returned error is ctx.Err()
+// if the flush did not finish in time, in which case the context given to the Transport is cancelled.
+func (l *Logger) FlushContext(ctx context.Context) (shared.FlushReport, error) {
+	var fallback func(string)
+	if override := l.loadConfig().flushFallback; override != nil {
+		fallback = override.Send
+	}
 
-func (l *Logger) prependWarn(msg string) string {
-	return l.prepender(shared.WarningMessage, msg)
+	report, err := shared.WaitForFlush(
+		ctx,
+		l.Flush(),
+		l.logsToPrint,
+		func() int { return int(atomic.LoadInt32(&l.inFlight)) },
+		fallback,
+	)
+	// Let a stuck transport know that nobody is waiting for it any more.
+	if err != nil {
+		l.cancel()
+	}
+	return report, err
 }
 
-func (l *Logger) prependCrit(msg string) string {
-	return l.prepender(shared.CriticalMessage, msg)
+// SetFlushFallback sets where the messages left in the buffer go when FlushContext gives up
+// waiting. Something simple that can't get stuck like stderr is a good choice.
+func (l *Logger) SetFlushFallback(fallback overrides.Overrider) {
+	l.updateConfig(func(c *config) { c.flushFallback = fallback })
 }
 
-func (l *Logger) prependDebug(msg string) string {
-	return l.prepender(shared.DebugMessage, msg)
+// skip tells the logging functions to not bother building a message if it is below threshold
+// or the logger has been flushed. Messages skipped here are counted as filtered or dropped.
+func (l *Logger) skip(threshold, level shared.Level) bool {
+	if l.lifecycle.IsShutdown() {
+		l.stats.Dropped()
+		return true
+	}
+	if level < threshold {
+		l.stats.Filtered(level)
+		return true
+	}
+	return false
 }
 
-// Infoln takes a string adds a new line to the end and sends it to be printed
-func (l *Logger) Infoln(msg ...interface{}) {
-	if l.shutdown {
+// prepend writes the timestamp and the level tag that start every message, in colour if the
+// colour mode asks for it.
+func (l *Logger) prepend(c *config, buf *shared.Buffer, level shared.Level) {
+	if !c.colour.Enabled(c.transport == nil && c.transportOverride == nil) {
+		buf.WriteString(c.timestampFunc())
+		buf.WriteByte(' ')
+		buf.WriteString(c.tag(level))
 		return
 	}
-	out := []interface{}{l.prependInfo("")}
-	out = append(out, msg...)
-	l.send(fmt.Sprintln(out...))
+	buf.WriteString(shared.ColourDim)
+	buf.WriteString(c.timestampFunc())
+	buf.WriteString(shared.ColourReset)
+	buf.WriteByte(' ')
+	buf.WriteString(shared.LevelColour(level))
+	buf.WriteString(c.tag(level))
+	buf.WriteString(shared.ColourReset)
 }
 
-// Warnln takes a string adds a new line to the end and sends it to be printed
-func (l *Logger) Warnln(msg ...interface{}) {
-	if l.shutdown {
+// SetColourMode sets when the level tags are coloured and the timestamps dimmed. The default,
+// shared.ColourAuto, colours messages printed to a terminal unless NO_COLOR is set.
+func (l *Logger) SetColourMode(mode shared.ColourMode) {
+	l.updateConfig(func(c *config) { c.colour = mode })
+}
+
+// Logln takes a level and a message, adds a new line to the end and sends it to be printed.
+func (l *Logger) Logln(level shared.Level, msg ...interface{}) {
+	l.logln(1, l.Level(), "", level, msg)
+}
+
+// Logf takes a level, a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed.
+func (l *Logger) Logf(level shared.Level, format string, vars ...interface{}) {
+	l.logf(1, l.Level(), "", level, format, vars)
+}
+
+// logln builds and sends a line message if level is not below threshold. name is the name of
+// the child logger that the message came from and is empty for messages from the logger itself.
+// The message is built straight into a pooled buffer.
+// depth is the number of frames between logln and the code that logged the message, 1 for the
+// logging functions that call logln directly, and is used to find the caller.
+func (l *Logger) logln(depth int, threshold shared.Level, name string, level shared.Level, msg []interface{}) {
+	if l.skip(threshold, level) {
 		return
 	}
-	out := []interface{}{l.prependWarn("")}
-	out = append(out, msg...)
-	l.send(fmt.Sprintln(out...))
+	c := l.loadConfig()
+	buf := shared.GetBuffer()
+	if c.format != nil {
+		writeSegments(c, buf, c.format.before, level, name)
+	} else {
+		l.prepend(c, buf, level)
+		if name != "" {
+			buf.WriteByte(' ')
+			buf.WriteString(name)
+			buf.WriteByte(':')
+		}
+		if len(msg) > 0 {
+			buf.WriteByte(' ')
+		}
+	}
+	fmt.Fprintln(buf, msg...)
+	if c.format != nil || c.caller || c.stacktraces {
+		// Keep the new line at the end of the message.
+		buf.Truncate(buf.Len() - 1)
+		if c.format != nil {
+			writeSegments(c, buf, c.format.after, level, name)
+		}
+		annotate(c, level, depth, buf)
+		buf.WriteByte('\n')
+	}
+	l.send(level, buf)
 }
 
-// Critln takes a string adds a new line to the end and sends it to be printed
-func (l *Logger) Critln(msg ...interface{}) {
-	if l.shutdown {
+// logf is the format version of logln.
+func (l *Logger) logf(depth int, threshold shared.Level, name string, level shared.Level, format string, vars []interface{}) {
+	if l.skip(threshold, level) {
 		return
 	}
-	out := []interface{}{l.prependCrit("")}
-	out = append(out, msg...)
-	l.send(fmt.Sprintln(out...))
+	c := l.loadConfig()
+	buf := shared.GetBuffer()
+	if c.format != nil {
+		writeSegments(c, buf, c.format.before, level, name)
+		fmt.Fprintf(buf, format, vars...)
+		writeSegments(c, buf, c.format.after, level, name)
+	} else {
+		l.prepend(c, buf, level)
+		mark := buf.Len()
+		buf.WriteByte(' ')
+		if name != "" {
+			buf.WriteString(name)
+			buf.WriteString(": ")
+		}
+		fmt.Fprintf(buf, format, vars...)
+		// Nothing to print after the tag, so drop the space.
+		if buf.Len() == mark+1 {
+			buf.Truncate(mark)
+		}
+	}
+	annotate(c, level, depth, buf)
+	l.send(level, buf)
+}
+
+// Traceln takes a string adds a new line to the end and sends it to be printed
+func (l *Logger) Traceln(msg ...interface{}) {
+	l.logln(1, l.Level(), "", shared.LevelTrace, msg)
 }
 
 // Debugln takes a string adds a new line to the end and sends it to be printed
 func (l *Logger) Debugln(msg ...interface{}) {
-	if l.shutdown {
-		return
-	}
-	if !l.printDebug {
-		return
-	}
-	out := []interface{}{l.prependDebug("")}
-	out = append(out, msg...)
-	l.send(fmt.Sprintln(out...))
+	l.logln(1, l.Level(), "", shared.LevelDebug, msg)
+}
+
+// Infoln takes a string adds a new line to the end and sends it to be printed
+func (l *Logger) Infoln(msg ...interface{}) {
+	l.logln(1, l.Level(), "", shared.LevelInfo, msg)
+}
+
+// Warnln takes a string adds a new line to the end and sends it to be printed
+func (l *Logger) Warnln(msg ...interface{}) {
+	l.logln(1, l.Level(), "", shared.LevelWarn, msg)
+}
+
+// Errorln takes a string adds a new line to the end and sends it to be printed
+func (l *Logger) Errorln(msg ...interface{}) {
+	l.logln(1, l.Level(), "", shared.LevelError, msg)
+}
+
+// Critln takes a string adds a new line to the end and sends it to be printed
+func (l *Logger) Critln(msg ...interface{}) {
+	l.logln(1, l.Level(), "", shared.LevelCrit, msg)
+}
+
+// Fatalln takes a string adds a new line to the end and sends it to be printed.
+// The logger is then flushed and the program exits with status 1.
+func (l *Logger) Fatalln(msg ...interface{}) {
+	l.logln(1, l.Level(), "", shared.LevelFatal, msg)
+	<-l.Flush()
+	exit(1)
+}
+
+// Panicln takes a string adds a new line to the end and sends it to be printed.
+// It waits for the message to be printed then panics with the message. The logger keeps running
+// in case the panic is recovered.
+func (l *Logger) Panicln(msg ...interface{}) {
+	l.logln(1, l.Level(), "", shared.LevelPanic, msg)
+	<-l.Sync()
+	panic(fmt.Sprintln(msg...))
+}
+
+// Tracef takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (l *Logger) Tracef(format string, vars ...interface{}) {
+	l.logf(1, l.Level(), "", shared.LevelTrace, format, vars)
+}
+
+// Debugf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (l *Logger) Debugf(format string, vars ...interface{}) {
+	l.logf(1, l.Level(), "", shared.LevelDebug, format, vars)
 }
 
 // Infof takes a format string and as many vars as needed, merges the format with vars
 // then sends the message to be printed
 func (l *Logger) Infof(format string, vars ...interface{}) {
-	if l.shutdown {
-		return
-	}
-	l.send(l.prependInfo(fmt.Sprintf(format, vars...)))
+	l.logf(1, l.Level(), "", shared.LevelInfo, format, vars)
 }
 
 // Warnf takes a format string and as many vars as needed, merges the format with vars
 // then sends the message to be printed
 func (l *Logger) Warnf(format string, vars ...interface{}) {
-	if l.shutdown {
-		return
-	}
-	l.send(l.prependWarn(fmt.Sprintf(format, vars...)))
+	l.logf(1, l.Level(), "", shared.LevelWarn, format, vars)
+}
+
+// Errorf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (l *Logger) Errorf(format string, vars ...interface{}) {
+	l.logf(1, l.Level(), "", shared.LevelError, format, vars)
 }
 
 // Critf takes a format string and as many vars as needed, merges the format with vars
 // then sends the message to be printed
 func (l *Logger) Critf(format string, vars ...interface{}) {
-	if l.shutdown {
-		return
-	}
-	l.send(l.prependCrit(fmt.Sprintf(format, vars...)))
+	l.logf(1, l.Level(), "", shared.LevelCrit, format, vars)
 }
 
-// Debugf takes a format string and as many vars as needed, merges the format with vars
-// then sends the message to be printed
-func (l *Logger) Debugf(format string, vars ...interface{}) {
-	if l.shutdown {
-		return
-	}
-	if !l.printDebug {
-		return
-	}
-	l.send(l.prependDebug(fmt.Sprintf(format, vars...)))
+// Fatalf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed. The logger is then flushed and the program exits
+// with status 1.
+func (l *Logger) Fatalf(format string, vars ...interface{}) {
+	l.logf(1, l.Level(), "", shared.LevelFatal, format, vars)
+	<-l.Flush()
+	exit(1)
+}
+
+// Panicf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed. It waits for the message to be printed then panics with
+// the message. The logger keeps running in case the panic is recovered.
+func (l *Logger) Panicf(format string, vars ...interface{}) {
+	l.logf(1, l.Level(), "", shared.LevelPanic, format, vars)
+	<-l.Sync()
+	panic(fmt.Sprintf(format, vars...))
 }
 
 // send will select the correct sending function for shipping logs.
-func (l *Logger) send(msg string) {
-	if l.auditmode {
-		shared.AuditSender(msg, l.logsToPrint)
+func (l *Logger) send(level shared.Level, msg *shared.Buffer) {
+	if !l.lifecycle.Enter() {
+		msg.Free()
+		l.stats.Dropped()
+		return
+	}
+	defer l.lifecycle.Leave()
+
+	if l.loadConfig().auditmode {
+		shared.AuditEntrySender(shared.Entry{Level: level, Message: msg}, l.logsToPrint)
+		l.stats.Accepted()
 		return
 	}
 
-	shared.BestEffortSender(msg, l.logsToPrint, l.droppedMessage)
+	if shared.BestEffortEntrySender(shared.Entry{Level: level, Message: msg}, l.logsToPrint, l.stats.Dropped) {
+		l.stats.Accepted()
+	}
 }
 
-func (l *Logger) droppedMessage() {
-	l.droppedMessages++
+// Stats returns a snapshot of what the logger has done with the messages it has been given.
+// It is safe to call from any goroutine.
+func (l *Logger) Stats() shared.Stats {
+	return l.stats.Snapshot(len(l.logsToPrint))
 }
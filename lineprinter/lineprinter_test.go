@@ -1,10 +1,19 @@
 package lineprinter
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/overrides"
+	"github.com/silverstagtech/loggos/shared"
 )
 
 func TestLoggerOverride(t *testing.T) {
@@ -197,3 +206,504 @@ func TestTimeStampFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestStats(t *testing.T) {
+	tracing := gotracer.New()
+
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.Infof("test message")
+	logger.Debugf("test message")
+	<-logger.Flush()
+
+	stats := logger.Stats()
+	if stats.Accepted != 1 || stats.DebugFiltered != 1 {
+		t.Logf("Stats did not count the messages correctly. Got: %+v", stats)
+		t.Fail()
+	}
+	if stats.BytesWritten != int64(len(tracing.Show()[0])) {
+		t.Logf("Stats has the wrong number of bytes written. Got: %d, Want: %d", stats.BytesWritten, len(tracing.Show()[0]))
+		t.Fail()
+	}
+}
+
+func TestStatsDropped(t *testing.T) {
+	// Nothing is reading from the buffer so the second message has nowhere to go.
+	logger := newLogger(1)
+	logger.Infof("first")
+	logger.Infof("second")
+
+	stats := logger.Stats()
+	if stats.Dropped != 1 || stats.BufferDepth != 1 {
+		t.Logf("Expected 1 dropped message and a buffer depth of 1. Got: %+v", stats)
+		t.Fail()
+	}
+}
+
+// countingOverrider counts the messages it is given and is safe to use while the logger
+// is being reconfigured.
+type countingOverrider struct {
+	count int64
+}
+
+func (co *countingOverrider) Send(string) {
+	atomic.AddInt64(&co.count, 1)
+}
+
+func TestConcurrentConfiguration(t *testing.T) {
+	// This test is only meaningful when run with the race detector, go test -race.
+	logger := New(100)
+	logger.OverridePrinter(&countingOverrider{})
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				toggle := n%2 == 0
+				logger.EnableDebugLogging(toggle)
+				logger.EnableAuditMode(toggle)
+				logger.OverrideTimeStamping(func() string { return "--static--" })
+				logger.OverridePrinter(&countingOverrider{})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				logger.Infof("concurrent message %d", n)
+				logger.Debugln("concurrent message", n)
+			}
+		}()
+	}
+	wg.Wait()
+	<-logger.Flush()
+}
+
+func TestFlushTwice(t *testing.T) {
+	logger := New(10)
+	first := logger.Flush()
+	second := logger.Flush()
+	<-first
+	<-second
+
+	if first != second {
+		t.Logf("Flushing twice returned different channels.")
+		t.Fail()
+	}
+}
+
+func TestSendWhileFlushing(t *testing.T) {
+	for _, audit := range []bool{false, true} {
+		co := &countingOverrider{}
+		logger := New(5)
+		logger.EnableAuditMode(audit)
+		logger.OverridePrinter(co)
+
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for n := 0; n < 100; n++ {
+					logger.Infof("message %d", n)
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-logger.Flush()
+		}()
+		wg.Wait()
+		<-logger.Flush()
+
+		stats := logger.Stats()
+		if stats.Accepted+stats.Dropped != 400 {
+			t.Logf("Audit mode %t: messages were lost without being counted. Got: %+v", audit, stats)
+			t.Fail()
+		}
+		if atomic.LoadInt64(&co.count) != stats.Accepted {
+			t.Logf("Audit mode %t: accepted messages were not printed. Printed %d, Stats: %+v", audit, co.count, stats)
+			t.Fail()
+		}
+	}
+}
+
+// blockingOverrider stands in for an output that has got stuck. It holds on to every message
+// until release is closed.
+type blockingOverrider struct {
+	release chan bool
+}
+
+func (bo *blockingOverrider) Send(string) {
+	<-bo.release
+}
+
+func TestFlushContext(t *testing.T) {
+	stuck := &blockingOverrider{release: make(chan bool)}
+	defer close(stuck.release)
+
+	logger := New(10)
+	logger.OverridePrinter(stuck)
+	logger.Infof("message 1")
+	logger.Infof("message 2")
+	logger.Infof("message 3")
+
+	// Wait for the logger to get stuck on the first message.
+	for logger.Stats().BufferDepth != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	report, err := logger.FlushContext(ctx)
+
+	if err != context.DeadlineExceeded {
+		t.Logf("FlushContext did not give back the context error. Got: %v", err)
+		t.Fail()
+	}
+	// No fallback is set so nothing can be recovered.
+	if report.InFlight != 1 || report.Buffered != 2 || report.Recovered != 0 {
+		t.Logf("FlushContext reported the wrong left overs. Got: %+v", report)
+		t.Fail()
+	}
+}
+
+func TestFlushContextFinishes(t *testing.T) {
+	tracing := gotracer.New()
+
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.Infof("test message")
+
+	report, err := logger.FlushContext(context.Background())
+	if err != nil || report.Unflushed() != 0 || tracing.Len() != 1 {
+		t.Logf("FlushContext did not flush the logger. Report: %+v, Error: %v, Messages: %v", report, err, tracing.Show())
+		t.Fail()
+	}
+}
+
+func TestOverrideTransport(t *testing.T) {
+	transport := overrides.TransportFunc(func(ctx context.Context, msg []byte) error {
+		// Behave like a transport stuck on a dead socket.
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	logger := New(10)
+	logger.OverrideTransport(transport)
+	logger.Infof("test message")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	logger.FlushContext(ctx)
+
+	// Giving up on the flush cancels the transport which then reports a failure.
+	<-logger.Flush()
+	if stats := logger.Stats(); stats.TransportFailures != 1 {
+		t.Logf("Cancelled transport was not counted as a failure. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestOverridePrecedence(t *testing.T) {
+	for _, transportLast := range []bool{false, true} {
+		printer := gotracer.New()
+		received := 0
+		transport := overrides.TransportFunc(func(_ context.Context, msg []byte) error {
+			received++
+			return nil
+		})
+
+		logger := New(10)
+		if transportLast {
+			logger.OverridePrinter(printer)
+			logger.OverrideTransport(transport)
+		} else {
+			logger.OverrideTransport(transport)
+			logger.OverridePrinter(printer)
+		}
+		logger.Infoln("test message")
+		<-logger.Flush()
+
+		if transportLast && (received != 1 || printer.Len() != 0) {
+			t.Logf("OverrideTransport called last did not win. Transport: %d, Printer: %v", received, printer.Show())
+			t.Fail()
+		}
+		if !transportLast && (received != 0 || printer.Len() != 1) {
+			t.Logf("OverridePrinter called last did not win. Transport: %d, Printer: %v", received, printer.Show())
+			t.Fail()
+		}
+	}
+}
+
+func TestTransportLevel(t *testing.T) {
+	levels := []shared.Level{}
+	transport := overrides.TransportFunc(func(ctx context.Context, msg []byte) error {
+		level, _ := overrides.LevelFromContext(ctx)
+		levels = append(levels, level)
+		return nil
+	})
+
+	logger := New(10)
+	logger.OverrideTransport(transport)
+	logger.Warnf("test message")
+	logger.Critln("test message")
+	<-logger.Flush()
+
+	if len(levels) != 2 || levels[0] != shared.LevelWarn || levels[1] != shared.LevelCrit {
+		t.Logf("Transport did not get the level of the messages. Got: %v", levels)
+		t.Fail()
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.SetLevel(shared.LevelWarn)
+
+	logger.Debugf("test message")
+	logger.Infoln("test message")
+	logger.Warnf("test message")
+	logger.Critln("test message")
+	<-logger.Flush()
+
+	if tracing.Len() != 2 {
+		t.Logf("Wanted only WARN and CRIT messages. Got: %v", tracing.Show())
+		t.Fail()
+	}
+	if stats := logger.Stats(); stats.DebugFiltered != 1 || stats.LevelFiltered != 1 {
+		t.Logf("Filtered messages were not counted. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestExtraLevels(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.SetLevel(shared.LevelTrace)
+
+	logger.Traceln("test message")
+	logger.Tracef("test %s", "message")
+	logger.Errorln("test message")
+	logger.Errorf("test %s", "message")
+	<-logger.Flush()
+
+	got := tracing.Show()
+	if len(got) != 4 {
+		t.Fatalf("Wanted 4 messages. Got: %v", got)
+	}
+	for i, tag := range []string{"TRACE", "TRACE", "ERROR", "ERROR"} {
+		if !regexp.MustCompile(" " + tag + " test message").MatchString(got[i]) {
+			t.Logf("Message %d does not carry the %s tag. Got: %s", i, tag, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestFatal(t *testing.T) {
+	defer func() { exit = os.Exit }()
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.Fatalf("test %s", "message")
+
+	if exitCode != 1 {
+		t.Logf("Fatalf did not exit with status 1. Got: %d", exitCode)
+		t.Fail()
+	}
+	// The logger must have been flushed before exiting.
+	if tracing.Len() != 1 {
+		t.Logf("Fatal message was not printed before exiting. Got: %v", tracing.Show())
+		t.Fail()
+	}
+}
+
+func TestPanic(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Logf("Panicln did not panic.")
+				t.Fail()
+			}
+		}()
+		logger.Panicln("test message")
+	}()
+
+	if tracing.Len() != 1 {
+		t.Logf("Panic message was not printed before panicking. Got: %v", tracing.Show())
+		t.Fail()
+	}
+
+	// The panic was recovered so the logger must still be running.
+	logger.Named("db").Infoln("after the panic")
+	<-logger.Flush()
+	if tracing.Len() != 2 {
+		t.Logf("Logger stopped after a recovered panic. Got: %v", tracing.Show())
+		t.Fail()
+	}
+}
+
+func TestWriter(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+
+	w := logger.Writer(shared.LevelWarn)
+	w.DetectLevels(true)
+	fmt.Fprint(w, "first line\n[DEBUG] filtered\n[crit] second ")
+	fmt.Fprint(w, "line\n")
+	<-logger.Flush()
+
+	expected := []string{
+		` WARN first line$`,
+		` CRIT second line$`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %q", len(expected), got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %q", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestColourMode(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.OverrideTimeStamping(func() string { return "now" })
+
+	// Overrides are never coloured automatically.
+	logger.Warnln("test message")
+	logger.SetColourMode(shared.ColourAlways)
+	logger.Warnf("test %s", "message")
+	<-logger.Flush()
+
+	expected := []string{
+		"now WARN test message\n",
+		shared.ColourDim + "now" + shared.ColourReset + " " + shared.LevelColour(shared.LevelWarn) + "WARN" + shared.ColourReset + " test message",
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %q", len(expected), got)
+	}
+	for i, want := range expected {
+		if got[i] != want {
+			t.Logf("Message %d is wrong. Got: %q, Want: %q", i, got[i], want)
+			t.Fail()
+		}
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+	logger.OverrideTimeStamping(func() string { return "now" })
+
+	if err := logger.SetFormat("{time} [{level}] {name} {pid}: {msg} {{done}}"); err != nil {
+		t.Fatalf("Failed to set the format. Error: %s", err)
+	}
+	logger.SetLevelTags(map[shared.Level]string{shared.LevelWarn: "WARNING"})
+	logger.SetLevelPadding(7)
+	logger.Infoln("test", "message")
+	logger.Named("db").Warnf("test %s", "message")
+	if err := logger.SetFormat(""); err != nil {
+		t.Fatalf("Failed to reset the format. Error: %s", err)
+	}
+	logger.Infoln("test message")
+	<-logger.Flush()
+
+	pid := strconv.Itoa(os.Getpid())
+	expected := []string{
+		"now [INFO   ]  " + pid + ": test message {done}\n",
+		"now [WARNING] db " + pid + ": test message {done}",
+		"now INFO    test message\n",
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %q", len(expected), got)
+	}
+	for i, want := range expected {
+		if got[i] != want {
+			t.Logf("Message %d is wrong. Got: %q, Want: %q", i, got[i], want)
+			t.Fail()
+		}
+	}
+}
+
+func TestSetFormatErrors(t *testing.T) {
+	logger := New(10)
+	defer func() { <-logger.Flush() }()
+
+	for _, template := range []string{
+		"{time} no message",
+		"{msg} {msg}",
+		"{time} {host} {msg}",
+		"{time {msg}",
+		"{msg} }",
+	} {
+		if err := logger.SetFormat(template); err == nil {
+			t.Logf("Format %q should have been rejected.", template)
+			t.Fail()
+		}
+	}
+}
+
+// levelRecorder is a LevelOverrider that keeps the level of each message.
+type levelRecorder struct {
+	lock   sync.Mutex
+	levels []shared.Level
+}
+
+func (lr *levelRecorder) Send(string) {}
+
+func (lr *levelRecorder) SendLevel(level shared.Level, _ string) {
+	lr.lock.Lock()
+	defer lr.lock.Unlock()
+	lr.levels = append(lr.levels, level)
+}
+
+func TestLevelOverrider(t *testing.T) {
+	recorder := &levelRecorder{}
+	logger := New(10)
+	logger.OverridePrinter(recorder)
+	logger.Infoln("test message")
+	logger.Critf("test %s", "message")
+	<-logger.Flush()
+
+	if len(recorder.levels) != 2 || recorder.levels[0] != shared.LevelInfo || recorder.levels[1] != shared.LevelCrit {
+		t.Logf("LevelOverrider did not get the levels of the messages. Got: %v", recorder.levels)
+		t.Fail()
+	}
+}
+
+type discardTransport struct{}
+
+func (discardTransport) Send(context.Context, []byte) error { return nil }
+
+func BenchmarkInfof(b *testing.B) {
+	logger := New(1000)
+	logger.OverrideTransport(discardTransport{})
+	logger.EnableAuditMode(true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Infof("request to %s finished with %d", "/api/v1/users", 200)
+	}
+	<-logger.Flush()
+}
@@ -0,0 +1,182 @@
+package lineprinter
+
+import (
+	"fmt"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// Child is a named logger that prints through the Logger that made it. It shares the buffer,
+// printing goroutine, settings and statistics of that Logger and puts its name in front of
+// every message. A Child is cheap to make and safe to use from any goroutine.
+type Child struct {
+	level  shared.LevelOverride
+	logger *Logger
+	parent *Child
+	name   string
+}
+
+// Named returns a child logger that puts name in front of its messages. The child uses the
+// level of the logger until it is given its own with SetLevel.
+func (l *Logger) Named(name string) *Child {
+	return &Child{
+		logger: l,
+		name:   name,
+	}
+}
+
+// Named returns a child of this child. The names are joined with a dot, so Named("pool") on
+// a child called db prints db.pool. The new child uses the level of this child until it is
+// given its own.
+func (c *Child) Named(name string) *Child {
+	return &Child{
+		logger: c.logger,
+		parent: c,
+		name:   c.name + "." + name,
+	}
+}
+
+// Name returns the name that the child puts in front of its messages.
+func (c *Child) Name() string {
+	return c.name
+}
+
+// SetLevel sets the lowest level of message that this child will print. It can be changed
+// at any time and does not change the level of the logger or any other child.
+func (c *Child) SetLevel(level shared.Level) {
+	c.level.Set(level)
+}
+
+// ResetLevel removes the level set with SetLevel so the child goes back to using the level
+// of its parent.
+func (c *Child) ResetLevel() {
+	c.level.Reset()
+}
+
+// Level returns the lowest level of message that this child will print.
+func (c *Child) Level() shared.Level {
+	if level, ok := c.level.Get(); ok {
+		return level
+	}
+	if c.parent != nil {
+		return c.parent.Level()
+	}
+	return c.logger.Level()
+}
+
+// Enabled tells you if a message of the given level would be printed by this child.
+func (c *Child) Enabled(level shared.Level) bool {
+	return level >= c.Level()
+}
+
+// Logln takes a level and a message, adds a new line to the end and sends it to be printed.
+func (c *Child) Logln(level shared.Level, msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, level, msg)
+}
+
+// Logf takes a level, a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed.
+func (c *Child) Logf(level shared.Level, format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, level, format, vars)
+}
+
+// Traceln takes a string adds a new line to the end and sends it to be printed
+func (c *Child) Traceln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelTrace, msg)
+}
+
+// Debugln takes a string adds a new line to the end and sends it to be printed
+func (c *Child) Debugln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelDebug, msg)
+}
+
+// Infoln takes a string adds a new line to the end and sends it to be printed
+func (c *Child) Infoln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelInfo, msg)
+}
+
+// Warnln takes a string adds a new line to the end and sends it to be printed
+func (c *Child) Warnln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelWarn, msg)
+}
+
+// Errorln takes a string adds a new line to the end and sends it to be printed
+func (c *Child) Errorln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelError, msg)
+}
+
+// Critln takes a string adds a new line to the end and sends it to be printed
+func (c *Child) Critln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelCrit, msg)
+}
+
+// Fatalln takes a string adds a new line to the end and sends it to be printed.
+// The logger that made the child is then flushed and the program exits with status 1.
+func (c *Child) Fatalln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelFatal, msg)
+	<-c.logger.Flush()
+	exit(1)
+}
+
+// Panicln takes a string adds a new line to the end and sends it to be printed.
+// It waits for the message to be printed then panics with the message. The logger keeps running
+// in case the panic is recovered.
+func (c *Child) Panicln(msg ...interface{}) {
+	c.logger.logln(1, c.Level(), c.name, shared.LevelPanic, msg)
+	<-c.logger.Sync()
+	panic(fmt.Sprintln(msg...))
+}
+
+// Tracef takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (c *Child) Tracef(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelTrace, format, vars)
+}
+
+// Debugf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (c *Child) Debugf(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelDebug, format, vars)
+}
+
+// Infof takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (c *Child) Infof(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelInfo, format, vars)
+}
+
+// Warnf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (c *Child) Warnf(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelWarn, format, vars)
+}
+
+// Errorf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (c *Child) Errorf(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelError, format, vars)
+}
+
+// Critf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed
+func (c *Child) Critf(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelCrit, format, vars)
+}
+
+// Fatalf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed. The logger that made the child is then flushed and
+// the program exits with status 1.
+func (c *Child) Fatalf(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelFatal, format, vars)
+	<-c.logger.Flush()
+	exit(1)
+}
+
+// Panicf takes a format string and as many vars as needed, merges the format with vars
+// then sends the message to be printed. It waits for the message to be printed then panics with
+// the message. The logger keeps running in case the panic is recovered.
+func (c *Child) Panicf(format string, vars ...interface{}) {
+	c.logger.logf(1, c.Level(), c.name, shared.LevelPanic, format, vars)
+	<-c.logger.Sync()
+	panic(fmt.Sprintf(format, vars...))
+}
@@ -0,0 +1,64 @@
+package lineprinter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+func TestNamed(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+
+	db := logger.Named("db")
+	db.Infoln("test message")
+	db.Named("pool").Warnf("test %s", "message")
+	logger.Infoln("test message")
+	<-logger.Flush()
+
+	expected := []string{
+		`INFO db: test message\n$`,
+		`WARN db.pool: test message$`,
+		`INFO test message\n$`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Children did not print through the parent. Got: %v", got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %q", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestNamedLevel(t *testing.T) {
+	tracing := gotracer.New()
+	logger := New(10)
+	logger.OverridePrinter(tracing)
+
+	db := logger.Named("db")
+	pool := db.Named("pool")
+	db.SetLevel(shared.LevelDebug)
+
+	logger.Debugln("test message")
+	db.Debugln("test message")
+	pool.Debugln("test message")
+
+	db.ResetLevel()
+	db.Debugln("test message")
+	<-logger.Flush()
+
+	if tracing.Len() != 2 {
+		t.Logf("Wanted debug messages from db and db.pool only. Got: %v", tracing.Show())
+		t.Fail()
+	}
+	if stats := logger.Stats(); stats.DebugFiltered != 2 {
+		t.Logf("Child filtered messages were not counted on the parent. Stats: %+v", stats)
+		t.Fail()
+	}
+}
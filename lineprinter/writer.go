@@ -0,0 +1,12 @@
+package lineprinter
+
+import "github.com/silverstagtech/loggos/shared"
+
+// Writer returns an io.Writer that logs each line written to it at level. Use it to catch the
+// output of libraries that write to an io.Writer or a *log.Logger. Call DetectLevels on it to
+// pick up levels like [WARN] at the start of lines.
+func (l *Logger) Writer(level shared.Level) *shared.LineWriter {
+	return shared.NewLineWriter(level, func(level shared.Level, line string) {
+		l.logf(1, l.Level(), "", level, "%s", []interface{}{line})
+	})
+}
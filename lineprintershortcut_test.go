@@ -1,9 +1,14 @@
 package loggos
 
 import (
+	"context"
+	"log"
+	"os"
+	"regexp"
 	"testing"
 
 	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/shared"
 )
 
 func callAllLineFunctions(checkPanic bool, t *testing.T) {
@@ -77,3 +82,176 @@ func TestLinSendPanic(t *testing.T) {
 	LineLoggerEnableDebugLogging(true)
 	callAllLineFunctions(true, t)
 }
+
+func TestSetLevel(t *testing.T) {
+	lineTracing := gotracer.New()
+	jsonTracing := gotracer.New()
+
+	// Start from fresh default loggers.
+	shutdownCurrentLoggers()
+	SetLevel(shared.LevelCrit)
+	DefaultLineLogger.OverridePrinter(lineTracing)
+	DefaultJSONLogger.OverridePrinter(jsonTracing)
+	callAllLineFunctions(false, t)
+	sendOnAllJSONFunctions(false, t)
+	shutdownCurrentLoggers()
+
+	if lineTracing.Len() != 2 || jsonTracing.Len() != 2 {
+		t.Logf("Wanted only CRIT messages. Line: %v, JSON: %v", lineTracing.Show(), jsonTracing.Show())
+		t.Fail()
+	}
+}
+
+func TestFatalFlushesDefaultLoggers(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+	defer func() { exit = os.Exit }()
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+
+	lineTracer := gotracer.New()
+	jsonTracer := gotracer.New()
+	startdefaultLineLogger()
+	startdefaultJSONLogger()
+	DefaultLineLogger.OverridePrinter(lineTracer)
+	DefaultJSONLogger.OverridePrinter(jsonTracer)
+
+	SendJSON(JSONErrorln("Test Message - JSONErrorln"))
+	Fatalln("Test Message - Fatalln")
+
+	if exitCode != 1 {
+		t.Logf("Fatalln did not exit with status 1. Got: %d", exitCode)
+		t.Fail()
+	}
+	if lineTracer.Len() != 1 || jsonTracer.Len() != 1 {
+		t.Logf("Default loggers were not flushed before exiting. Line: %v, JSON: %v", lineTracer.Show(), jsonTracer.Show())
+		t.Fail()
+	}
+}
+
+func TestPanicFlushesDefaultLoggers(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+
+	jsonTracer := gotracer.New()
+	startdefaultJSONLogger()
+	DefaultJSONLogger.OverridePrinter(jsonTracer)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Logf("SendJSONPanic did not panic.")
+				t.Fail()
+			}
+		}()
+		SendJSONPanic(JSONPanicf("Test Message - %s", "JSONPanicf"))
+	}()
+
+	if jsonTracer.Len() != 1 {
+		t.Logf("Default JSON logger was not flushed before panicking. Got: %v", jsonTracer.Show())
+		t.Fail()
+	}
+}
+
+func TestLoggingAfterRecoveredPanic(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+
+	lineTracer := gotracer.New()
+	jsonTracer := gotracer.New()
+	startdefaultLineLogger()
+	startdefaultJSONLogger()
+	DefaultLineLogger.OverridePrinter(lineTracer)
+	DefaultJSONLogger.OverridePrinter(jsonTracer)
+
+	for _, panics := range []func(){
+		func() { Panicln("Test Message - Panicln") },
+		func() { Panicf("Test Message - %s", "Panicf") },
+		func() { SendJSONPanic(JSONPanicln("Test Message - SendJSONPanic")) },
+	} {
+		func() {
+			defer func() { recover() }()
+			panics()
+		}()
+	}
+
+	Infoln("Test Message - after the panics")
+	Info("Test Message - after the panics")
+	<-Flush()
+
+	if lineTracer.Len() != 3 || jsonTracer.Len() != 2 {
+		t.Logf("Default loggers stopped after a recovered panic. Line: %v, JSON: %v", lineTracer.Show(), jsonTracer.Show())
+		t.Fail()
+	}
+}
+
+func TestShortcutCaller(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+
+	lineTracing := gotracer.New()
+	jsonTracing := gotracer.New()
+	startdefaultLineLogger()
+	startdefaultJSONLogger()
+	DefaultLineLogger.OverridePrinter(lineTracing)
+	DefaultJSONLogger.OverridePrinter(jsonTracing)
+	EnableCaller(true)
+
+	// The shortcuts must report this file rather than themselves.
+	Infof("Test Message - %s", "Infof")
+	InfofContext(context.Background(), "Test Message - %s", "InfofContext")
+	Info("Test Message - Info")
+	SendJSON(JSONInfoln("Test Message - SendJSON"))
+	// Calling the default loggers directly must report this file too.
+	DefaultLineLogger.Infof("Test Message - %s", "DefaultLineLogger.Infof")
+	DefaultLineLogger.Named("db").Infoln("Test Message - Named.Infoln")
+	DefaultJSONLogger.Info("Test Message - DefaultJSONLogger.Info")
+	DefaultJSONLogger.Named("db").Info("Test Message - Named.Info")
+	DefaultJSONLogger.Send(JSONInfoln("Test Message - DefaultJSONLogger.Send"))
+	<-Flush()
+
+	for _, got := range append(lineTracing.Show(), jsonTracing.Show()...) {
+		if !regexp.MustCompile(`lineprintershortcut_test.go`).MatchString(got) || !regexp.MustCompile(`loggos.TestShortcutCaller`).MatchString(got) {
+			t.Logf("Shortcut did not report the caller. Got: %s", got)
+			t.Fail()
+		}
+	}
+	if lineTracing.Len() != 4 || jsonTracing.Len() != 5 {
+		t.Logf("Wanted 4 line and 5 JSON messages. Got: %v %v", lineTracing.Show(), jsonTracing.Show())
+		t.Fail()
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	shutdownCurrentLoggers()
+	defer shutdownCurrentLoggers()
+
+	tracing := gotracer.New()
+	startdefaultLineLogger()
+	DefaultLineLogger.OverridePrinter(tracing)
+
+	restore := RedirectStdLog(shared.LevelInfo)
+	log.Println("Test Message - log.Println")
+	log.Printf("[ERROR] Test Message - %s", "log.Printf")
+	restore()
+	<-Flush()
+
+	expected := []string{
+		` INFO Test Message - log.Println$`,
+		` ERROR Test Message - log.Printf$`,
+	}
+	got := tracing.Show()
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d messages. Got: %q", len(expected), got)
+	}
+	for i, matcher := range expected {
+		if !regexp.MustCompile(matcher).MatchString(got[i]) {
+			t.Logf("Message %d does not match %s. Got: %q", i, matcher, got[i])
+			t.Fail()
+		}
+	}
+	if log.Flags() != log.LstdFlags {
+		t.Logf("The log package flags were not put back. Got: %d", log.Flags())
+		t.Fail()
+	}
+}
@@ -1,10 +1,13 @@
 package loggos
 
 import (
+	"context"
 	"sync"
 
 	"github.com/silverstagtech/loggos/jsonprinter"
 	"github.com/silverstagtech/loggos/lineprinter"
+	"github.com/silverstagtech/loggos/overrides"
+	"github.com/silverstagtech/loggos/shared"
 )
 
 var (
@@ -62,3 +65,119 @@ func Flush() chan bool {
 
 	return c
 }
+
+// Sync on the package waits for the default loggers that you have made use of to print every
+// message sent before the call, without stopping them. The channel is closed once they have.
+func Sync() chan bool {
+	c := make(chan bool)
+
+	go func() {
+		if DefaultJSONLogger != nil {
+			<-DefaultJSONLogger.Sync()
+		}
+		if DefaultLineLogger != nil {
+			<-DefaultLineLogger.Sync()
+		}
+		close(c)
+	}()
+
+	return c
+}
+
+// FlushContext on the package will stop the default logging engines that you have made use of
+// like Flush but stops waiting when ctx expires. The report is the merged report of the default
+// loggers. The returned error is ctx.Err() if they did not finish in time.
+func FlushContext(ctx context.Context) (shared.FlushReport, error) {
+	type result struct {
+		report shared.FlushReport
+		err    error
+	}
+
+	results := make(chan result, 2)
+	waiting := 0
+
+	if DefaultJSONLogger != nil {
+		waiting++
+		go func() {
+			report, err := DefaultJSONLogger.FlushContext(ctx)
+			results <- result{report: report, err: err}
+		}()
+	}
+
+	if DefaultLineLogger != nil {
+		waiting++
+		go func() {
+			report, err := DefaultLineLogger.FlushContext(ctx)
+			results <- result{report: report, err: err}
+		}()
+	}
+
+	report := shared.FlushReport{}
+	var err error
+	for ; waiting > 0; waiting-- {
+		r := <-results
+		report = report.Merge(r.report)
+		if err == nil {
+			err = r.err
+		}
+	}
+
+	return report, err
+}
+
+// SetFlushFallback sets the flush fallback on the default loggers that you have made use of.
+// See FlushContext.
+func SetFlushFallback(fallback overrides.Overrider) {
+	if DefaultJSONLogger != nil {
+		DefaultJSONLogger.SetFlushFallback(fallback)
+	}
+	if DefaultLineLogger != nil {
+		DefaultLineLogger.SetFlushFallback(fallback)
+	}
+}
+
+// SetLevel sets the lowest level of message that the default line and JSON loggers will print.
+// Both loggers are started if they are not already.
+func SetLevel(level shared.Level) {
+	JSONLoggerSetLevel(level)
+	LineLoggerSetLevel(level)
+}
+
+// EnableCaller makes the default line and JSON loggers record where each message was logged from.
+// Both loggers are started if they are not already.
+func EnableCaller(toggle bool) {
+	startdefaultJSONLogger()
+	startdefaultLineLogger()
+	DefaultJSONLogger.EnableCaller(toggle)
+	DefaultLineLogger.EnableCaller(toggle)
+}
+
+// EnableStacktraces makes the default line and JSON loggers write a stack trace for messages at
+// level or above. Both loggers are started if they are not already.
+func EnableStacktraces(level shared.Level) {
+	startdefaultJSONLogger()
+	startdefaultLineLogger()
+	DefaultJSONLogger.EnableStacktraces(level)
+	DefaultLineLogger.EnableStacktraces(level)
+}
+
+// SetColourMode sets when the default line and JSON loggers write coloured output. Both
+// loggers are started if they are not already.
+func SetColourMode(mode shared.ColourMode) {
+	startdefaultJSONLogger()
+	startdefaultLineLogger()
+	DefaultJSONLogger.SetColourMode(mode)
+	DefaultLineLogger.SetColourMode(mode)
+}
+
+// Stats returns the merged statistics of the default loggers that you have made use of.
+func Stats() shared.Stats {
+	stats := shared.Stats{}
+	if DefaultJSONLogger != nil {
+		stats = stats.Merge(DefaultJSONLogger.Stats())
+	}
+	if DefaultLineLogger != nil {
+		stats = stats.Merge(DefaultLineLogger.Stats())
+	}
+	return stats
+}
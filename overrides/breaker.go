@@ -0,0 +1,88 @@
+package overrides
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a CircuitBreaker that is not letting messages through.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker is a Transport that stops calling the Transport it wraps once it has failed
+// a threshold of times in a row. Messages fail straight away with ErrCircuitOpen until the cooldown
+// has passed, then one message is let through to test the water. If it works the breaker closes
+// again, if not it stays open for another cooldown.
+type CircuitBreaker struct {
+	transport Transport
+	threshold int
+	cooldown  time.Duration
+
+	lock     sync.Mutex
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// NewCircuitBreaker wraps transport in a CircuitBreaker. A threshold below 1 is treated as 1.
+func NewCircuitBreaker(transport Transport, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		transport: transport,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Send sends msg if the breaker is closed or ready to test the water.
+func (cb *CircuitBreaker) Send(ctx context.Context, msg []byte) error {
+	if !cb.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := cb.transport.Send(ctx, msg)
+	cb.record(err)
+	return err
+}
+
+// IsOpen tells you if the breaker is currently refusing messages.
+func (cb *CircuitBreaker) IsOpen() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return cb.failures >= cb.threshold && cb.now().Sub(cb.openedAt) < cb.cooldown
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if cb.failures < cb.threshold {
+		return true
+	}
+	if cb.now().Sub(cb.openedAt) < cb.cooldown {
+		return false
+	}
+
+	// Let this message test the water and hold the rest back until it is done.
+	cb.openedAt = cb.now()
+	return true
+}
+
+func (cb *CircuitBreaker) record(err error) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if err == nil {
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.openedAt = cb.now()
+	}
+}
@@ -0,0 +1,49 @@
+package overrides
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	ft := &flakyTransport{failures: 3}
+	cb := NewCircuitBreaker(ft, 2, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	cb.Send(context.Background(), []byte("1"))
+	cb.Send(context.Background(), []byte("2"))
+
+	if !cb.IsOpen() {
+		t.Logf("Breaker did not open after reaching the threshold.")
+		t.FailNow()
+	}
+	if err := cb.Send(context.Background(), []byte("3")); err != ErrCircuitOpen {
+		t.Logf("Open breaker did not refuse the message. Got: %v", err)
+		t.Fail()
+	}
+	if ft.calls != 2 {
+		t.Logf("Open breaker called the transport. Calls: %d", ft.calls)
+		t.Fail()
+	}
+
+	// Test the water, the transport still fails so the breaker stays open.
+	now = now.Add(time.Minute)
+	cb.Send(context.Background(), []byte("4"))
+	if !cb.IsOpen() {
+		t.Logf("Breaker closed after a failed test message.")
+		t.Fail()
+	}
+
+	// The transport has recovered now.
+	now = now.Add(time.Minute)
+	if err := cb.Send(context.Background(), []byte("5")); err != nil {
+		t.Logf("Breaker did not let the test message through. Got: %v", err)
+		t.Fail()
+	}
+	if cb.IsOpen() {
+		t.Logf("Breaker did not close after a good test message.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,27 @@
+package overrides
+
+import "context"
+
+// Fallback is a Transport that hands the message to a secondary Transport when the primary fails.
+// Fallbacks can be chained by using a Fallback as the secondary.
+type Fallback struct {
+	primary   Transport
+	secondary Transport
+}
+
+// NewFallback creates a Fallback that tries primary first and then secondary.
+func NewFallback(primary, secondary Transport) *Fallback {
+	return &Fallback{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+// Send sends msg to the primary Transport and if that fails to the secondary.
+// The secondary's error is returned if both fail.
+func (f *Fallback) Send(ctx context.Context, msg []byte) error {
+	if err := f.primary.Send(ctx, msg); err == nil {
+		return nil
+	}
+	return f.secondary.Send(ctx, msg)
+}
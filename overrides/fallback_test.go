@@ -0,0 +1,51 @@
+package overrides
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFallback(t *testing.T) {
+	primary := &flakyTransport{failures: 1}
+	secondary := &flakyTransport{}
+	f := NewFallback(primary, secondary)
+
+	if err := f.Send(context.Background(), []byte("first")); err != nil {
+		t.Logf("Fallback failed even though the secondary works. Error: %s", err)
+		t.Fail()
+	}
+	if len(secondary.received) != 1 {
+		t.Logf("Secondary did not get the message the primary failed on.")
+		t.Fail()
+	}
+
+	f.Send(context.Background(), []byte("second"))
+	if len(primary.received) != 1 || len(secondary.received) != 1 {
+		t.Logf("Working primary should get the message. Primary: %d, Secondary: %d", len(primary.received), len(secondary.received))
+		t.Fail()
+	}
+
+	broken := NewFallback(&flakyTransport{failures: 1}, &flakyTransport{failures: 1})
+	if err := broken.Send(context.Background(), []byte("lost")); err == nil {
+		t.Logf("Fallback reported success when both transports failed.")
+		t.Fail()
+	}
+}
+
+func TestFromOverrider(t *testing.T) {
+	received := []string{}
+	o := overriderFunc(func(msg string) { received = append(received, msg) })
+
+	if err := FromOverrider(o).Send(context.Background(), []byte("test message")); err != nil {
+		t.Logf("A wrapped Overrider should never fail. Error: %s", err)
+		t.Fail()
+	}
+	if len(received) != 1 || received[0] != "test message" {
+		t.Logf("Wrapped Overrider did not get the message. Got: %v", received)
+		t.Fail()
+	}
+}
+
+type overriderFunc func(string)
+
+func (f overriderFunc) Send(msg string) { f(msg) }
@@ -0,0 +1,10 @@
+package overrides
+
+import "context"
+
+// Flusher can be implemented by a Overrider or Transport that holds on to messages, for example
+// to batch them up. Printers call Flush once their own buffer is empty when they are flushed so
+// that nothing is left behind.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
@@ -0,0 +1,22 @@
+package overrides
+
+import (
+	"context"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+type levelKey struct{}
+
+// ContextWithLevel returns a copy of ctx that carries the level of the message being sent.
+// Printers use it so that a Transport can tell how important a message is.
+func ContextWithLevel(ctx context.Context, level shared.Level) context.Context {
+	return context.WithValue(ctx, levelKey{}, level)
+}
+
+// LevelFromContext returns the level of the message being sent. The bool is false if the
+// context does not carry a level.
+func LevelFromContext(ctx context.Context) (shared.Level, bool) {
+	level, ok := ctx.Value(levelKey{}).(shared.Level)
+	return level, ok
+}
@@ -1,7 +1,16 @@
 package overrides
 
+import "github.com/silverstagtech/loggos/shared"
+
 // Overrider is an interface that has a Send method.
 // It is used to override the printing of logs in the line or JSON logger.
 type Overrider interface {
 	Send(string)
 }
+
+// LevelOverrider is a Overrider that also wants to know the level of each message. Printers call
+// SendLevel rather than Send on Overriders that have it.
+type LevelOverrider interface {
+	Overrider
+	SendLevel(level shared.Level, msg string)
+}
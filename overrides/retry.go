@@ -0,0 +1,57 @@
+package overrides
+
+import (
+	"context"
+	"time"
+)
+
+// Retry is a Transport that tries again when the Transport that it wraps fails.
+// It waits between attempts, doubling the wait each time up to a maximum.
+type Retry struct {
+	transport    Transport
+	attempts     int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+}
+
+// NewRetry wraps transport so that each message is tried up to attempts times, at least once.
+// The first retry waits initialDelay, each retry after that waits twice as long as the
+// last, but never longer than maxDelay.
+func NewRetry(transport Transport, attempts int, initialDelay, maxDelay time.Duration) *Retry {
+	return &Retry{
+		transport:    transport,
+		attempts:     attempts,
+		initialDelay: initialDelay,
+		maxDelay:     maxDelay,
+	}
+}
+
+// Send tries to send msg until it works, it runs out of attempts or ctx is done.
+// The error from the last attempt is returned if all attempts fail.
+func (r *Retry) Send(ctx context.Context, msg []byte) error {
+	delay := r.initialDelay
+	var err error
+
+	for attempt := 0; attempt < r.attempts || attempt == 0; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			delay *= 2
+			if r.maxDelay > 0 && delay > r.maxDelay {
+				delay = r.maxDelay
+			}
+		}
+
+		if err = r.transport.Send(ctx, msg); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,88 @@
+package overrides
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyTransport fails until it has been called failures times.
+type flakyTransport struct {
+	failures int
+	calls    int
+	received [][]byte
+}
+
+func (ft *flakyTransport) Send(_ context.Context, msg []byte) error {
+	ft.calls++
+	if ft.calls <= ft.failures {
+		return errors.New("flaky transport failed")
+	}
+	ft.received = append(ft.received, msg)
+	return nil
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		attempts  int
+		wantError bool
+		wantCalls int
+	}{
+		{
+			name:      "works first time",
+			failures:  0,
+			attempts:  3,
+			wantError: false,
+			wantCalls: 1,
+		},
+		{
+			name:      "works on the last attempt",
+			failures:  2,
+			attempts:  3,
+			wantError: false,
+			wantCalls: 3,
+		},
+		{
+			name:      "runs out of attempts",
+			failures:  5,
+			attempts:  3,
+			wantError: true,
+			wantCalls: 3,
+		},
+	}
+
+	for _, test := range tests {
+		ft := &flakyTransport{failures: test.failures}
+		r := NewRetry(ft, test.attempts, time.Microsecond, time.Millisecond)
+		err := r.Send(context.Background(), []byte("test message"))
+
+		if (err != nil) != test.wantError {
+			t.Logf("%s - unexpected error result. Got: %v", test.name, err)
+			t.Fail()
+		}
+		if ft.calls != test.wantCalls {
+			t.Logf("%s - wanted %d calls but got %d", test.name, test.wantCalls, ft.calls)
+			t.Fail()
+		}
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	ft := &flakyTransport{failures: 10}
+	r := NewRetry(ft, 10, time.Hour, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := r.Send(ctx, []byte("test message")); err == nil {
+		t.Logf("Retry reported success with a failing transport.")
+		t.Fail()
+	}
+	if ft.calls != 1 {
+		t.Logf("Retry kept going after the context was cancelled. Calls: %d", ft.calls)
+		t.Fail()
+	}
+}
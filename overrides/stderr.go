@@ -0,0 +1,15 @@
+package overrides
+
+import (
+	"fmt"
+	"os"
+)
+
+// Stderr is a Overrider that prints messages to stderr.
+// It is a good choice for a flush fallback as it is unlikely to get stuck.
+type Stderr struct{}
+
+// Send prints the message to stderr.
+func (Stderr) Send(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+}
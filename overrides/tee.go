@@ -0,0 +1,211 @@
+package overrides
+
+import (
+	"context"
+	"sync"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// TeeOutput describes one of the outputs of a Tee.
+type TeeOutput struct {
+	// Transport is where the messages for this output go.
+	Transport Transport
+	// Overrider is used for this output when Transport is nil.
+	Overrider Overrider
+	// MinLevel is the lowest level of message that this output wants.
+	MinLevel shared.Level
+	// Buffer is how many messages can wait for this output.
+	Buffer uint
+	// AuditMode makes the Tee wait for space in this outputs buffer rather than dropping messages.
+	AuditMode bool
+}
+
+// Tee is a Transport that copies each message to several outputs. Every output has its own
+// minimum level, buffer and goroutine so a slow output does not hold up the others unless it is
+// in audit mode. Pass it to OverrideTransport on either printer, or pass Overrider to
+// OverridePrinter.
+type Tee struct {
+	lifecycle shared.Lifecycle
+	outputs   []*teeOutput
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+type teeOutput struct {
+	// stats is kept first so that its counters are 64 bit aligned for atomic access.
+	stats shared.Counters
+	TeeOutput
+	messages chan teeEntry
+	// flusher is the output itself, which may be a Flusher.
+	flusher interface{}
+}
+
+type teeEntry struct {
+	level    shared.Level
+	hasLevel bool
+	msg      []byte
+}
+
+// NewTee creates a Tee and starts its outputs ready for messages. Outputs that have neither a
+// Transport nor a Overrider are left out.
+func NewTee(outputs ...TeeOutput) *Tee {
+	t := &Tee{
+		outputs: make([]*teeOutput, 0, len(outputs)),
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+
+	for _, output := range outputs {
+		if output.Transport == nil && output.Overrider == nil {
+			continue
+		}
+		o := &teeOutput{
+			TeeOutput: output,
+			messages:  make(chan teeEntry, output.Buffer),
+			flusher:   output.Transport,
+		}
+		if o.Transport == nil {
+			o.Transport = FromOverrider(output.Overrider)
+			o.flusher = output.Overrider
+		}
+		t.outputs = append(t.outputs, o)
+		t.wg.Add(1)
+		go t.run(o)
+	}
+
+	return t
+}
+
+// Send copies msg into the buffer of every output that wants a message of its level. Messages
+// that do not carry a level are sent to every output. Outputs are fed from their own goroutine
+// so Send never reports their failures, look at Stats for those.
+// msg is shared between the outputs so Transports used in a Tee must not change it.
+func (t *Tee) Send(ctx context.Context, msg []byte) error {
+	// The outputs send msg after Send has returned, by which time the printer has reused it.
+	stored := make([]byte, len(msg))
+	copy(stored, msg)
+	t.send(ctx, stored)
+	return nil
+}
+
+// send puts msg into the buffers of the outputs. msg must belong to the Tee as it is not copied.
+func (t *Tee) send(ctx context.Context, msg []byte) {
+	if !t.lifecycle.Enter() {
+		for _, o := range t.outputs {
+			o.stats.Dropped()
+		}
+		return
+	}
+	defer t.lifecycle.Leave()
+
+	level, hasLevel := LevelFromContext(ctx)
+	entry := teeEntry{level: level, hasLevel: hasLevel, msg: msg}
+
+	for _, o := range t.outputs {
+		if hasLevel && level < o.MinLevel {
+			continue
+		}
+
+		if o.AuditMode {
+			select {
+			case o.messages <- entry:
+				o.stats.Accepted()
+			case <-ctx.Done():
+				o.stats.Dropped()
+			}
+			continue
+		}
+
+		select {
+		case o.messages <- entry:
+			o.stats.Accepted()
+		default:
+			o.stats.Dropped()
+		}
+	}
+}
+
+// Overrider returns a Overrider that sends to the Tee so that it can be passed to
+// OverridePrinter. The printers give it the level of each message so MinLevel still applies, and
+// flushing the printer flushes the Tee.
+func (t *Tee) Overrider() LevelOverrider {
+	return teeOverrider{tee: t}
+}
+
+type teeOverrider struct {
+	tee *Tee
+}
+
+// Send sends msg to every output as it has no level.
+func (o teeOverrider) Send(msg string) {
+	o.tee.send(context.Background(), []byte(msg))
+}
+
+// SendLevel sends msg to the outputs that want a message of level.
+func (o teeOverrider) SendLevel(level shared.Level, msg string) {
+	o.tee.send(ContextWithLevel(context.Background(), level), []byte(msg))
+}
+
+// Flush flushes the Tee.
+func (o teeOverrider) Flush(ctx context.Context) error {
+	return o.tee.Flush(ctx)
+}
+
+// Flush stops the Tee from taking more messages and waits for every output to empty its buffer.
+// Outputs that are Flushers are flushed too. If ctx expires first the outputs are told to give up
+// and ctx.Err() is returned.
+func (t *Tee) Flush(ctx context.Context) error {
+	t.lifecycle.Shutdown(func() {
+		for _, o := range t.outputs {
+			close(o.messages)
+		}
+	})
+
+	done := make(chan bool)
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		return ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of the statistics of each output, in the order they were given to NewTee.
+// Outputs that NewTee left out have no statistics.
+func (t *Tee) Stats() []shared.Stats {
+	stats := make([]shared.Stats, len(t.outputs))
+	for i, o := range t.outputs {
+		stats[i] = o.stats.Snapshot(len(o.messages))
+	}
+	return stats
+}
+
+func (t *Tee) run(o *teeOutput) {
+	defer t.wg.Done()
+
+	for entry := range o.messages {
+		ctx := t.ctx
+		if entry.hasLevel {
+			ctx = ContextWithLevel(ctx, entry.level)
+		}
+
+		if err := o.Transport.Send(ctx, entry.msg); err != nil {
+			o.stats.TransportFailed()
+			continue
+		}
+		o.stats.Written(len(entry.msg))
+	}
+
+	if flusher, ok := o.flusher.(Flusher); ok {
+		if err := flusher.Flush(t.ctx); err != nil {
+			o.stats.TransportFailed()
+		}
+	}
+}
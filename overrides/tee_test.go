@@ -0,0 +1,190 @@
+package overrides
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// collectingTransport keeps every message it is sent and is safe to use from many goroutines.
+type collectingTransport struct {
+	lock     sync.Mutex
+	received []string
+	flushed  bool
+}
+
+func (ct *collectingTransport) Send(_ context.Context, msg []byte) error {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	ct.received = append(ct.received, string(msg))
+	return nil
+}
+
+func (ct *collectingTransport) Flush(context.Context) error {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	ct.flushed = true
+	return nil
+}
+
+func (ct *collectingTransport) Len() int {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+	return len(ct.received)
+}
+
+func TestTeeLevels(t *testing.T) {
+	everything := &collectingTransport{}
+	critical := &collectingTransport{}
+
+	tee := NewTee(
+		TeeOutput{Transport: everything, MinLevel: shared.LevelDebug, Buffer: 10},
+		TeeOutput{Transport: critical, MinLevel: shared.LevelCrit, Buffer: 10},
+	)
+
+	for _, level := range []shared.Level{shared.LevelDebug, shared.LevelInfo, shared.LevelWarn, shared.LevelCrit} {
+		tee.Send(ContextWithLevel(context.Background(), level), []byte(level.String()))
+	}
+	// Messages without a level go everywhere.
+	tee.Send(context.Background(), []byte("no level"))
+
+	if err := tee.Flush(context.Background()); err != nil {
+		t.Logf("Flushing the tee failed. Error: %s", err)
+		t.FailNow()
+	}
+
+	if everything.Len() != 5 {
+		t.Logf("Output with the lowest level did not get every message. Got: %v", everything.received)
+		t.Fail()
+	}
+	if critical.Len() != 2 || critical.received[0] != shared.CriticalMessage {
+		t.Logf("Critical output got the wrong messages. Got: %v", critical.received)
+		t.Fail()
+	}
+	if !everything.flushed || !critical.flushed {
+		t.Logf("Flushing the tee did not flush its outputs.")
+		t.Fail()
+	}
+}
+
+func TestTeeSlowOutput(t *testing.T) {
+	release := make(chan bool)
+	slow := TransportFunc(func(context.Context, []byte) error {
+		<-release
+		return nil
+	})
+	fast := &collectingTransport{}
+
+	tee := NewTee(
+		TeeOutput{Transport: slow, Buffer: 1},
+		TeeOutput{Transport: fast, Buffer: 10},
+	)
+
+	sent := make(chan bool)
+	go func() {
+		for i := 0; i < 10; i++ {
+			tee.Send(context.Background(), []byte("test message"))
+		}
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Logf("A slow best effort output held up the tee.")
+		t.FailNow()
+	}
+	close(release)
+	tee.Flush(context.Background())
+
+	if fast.Len() != 10 {
+		t.Logf("Fast output did not get every message. Got %d", fast.Len())
+		t.Fail()
+	}
+	stats := tee.Stats()
+	if stats[0].Dropped == 0 || stats[1].Dropped != 0 {
+		t.Logf("Only the slow output should drop messages. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestTeeFlushDeadline(t *testing.T) {
+	stuck := TransportFunc(func(ctx context.Context, _ []byte) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	tee := NewTee(TeeOutput{Transport: stuck, Buffer: 10})
+	tee.Send(context.Background(), []byte("test message"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := tee.Flush(ctx); err != context.DeadlineExceeded {
+		t.Logf("Flush did not give up on a stuck output. Got: %v", err)
+		t.Fail()
+	}
+
+	// Sending after a flush must not panic.
+	tee.Send(context.Background(), []byte("late message"))
+}
+
+// collectingOverrider keeps every message it is sent and is safe to use from many goroutines.
+type collectingOverrider struct {
+	collectingTransport
+}
+
+func (co *collectingOverrider) Send(msg string) {
+	co.collectingTransport.Send(context.Background(), []byte(msg))
+}
+
+func TestTeeOverrider(t *testing.T) {
+	everything := &collectingOverrider{}
+	critical := &collectingOverrider{}
+
+	tee := NewTee(
+		TeeOutput{Overrider: everything, MinLevel: shared.LevelDebug, Buffer: 10},
+		TeeOutput{Overrider: critical, MinLevel: shared.LevelCrit, Buffer: 10},
+	)
+
+	var override Overrider = tee.Overrider()
+	override.(LevelOverrider).SendLevel(shared.LevelInfo, "info")
+	override.(LevelOverrider).SendLevel(shared.LevelCrit, "crit")
+	override.Send("no level")
+
+	if err := override.(Flusher).Flush(context.Background()); err != nil {
+		t.Logf("Flushing the tee failed. Error: %s", err)
+		t.FailNow()
+	}
+
+	if everything.Len() != 3 || critical.Len() != 2 {
+		t.Logf("Outputs got the wrong messages. Everything: %v, Critical: %v", everything.received, critical.received)
+		t.Fail()
+	}
+	if !everything.flushed || !critical.flushed {
+		t.Logf("Overrider outputs that are Flushers were not flushed.")
+		t.Fail()
+	}
+}
+
+func TestTeeSkipsEmptyOutputs(t *testing.T) {
+	output := &collectingTransport{}
+	tee := NewTee(
+		TeeOutput{MinLevel: shared.LevelDebug, Buffer: 10},
+		TeeOutput{Transport: output, Buffer: 10},
+	)
+
+	tee.Send(context.Background(), []byte("test message"))
+	tee.Overrider().Send("test message")
+	if err := tee.Flush(context.Background()); err != nil {
+		t.Logf("Flushing the tee failed. Error: %s", err)
+		t.FailNow()
+	}
+
+	if output.Len() != 2 || len(tee.Stats()) != 1 {
+		t.Logf("Output without a Transport or Overrider was not left out. Got: %v, Stats: %+v", output.received, tee.Stats())
+		t.Fail()
+	}
+}
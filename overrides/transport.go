@@ -0,0 +1,29 @@
+package overrides
+
+import "context"
+
+// Transport is used to override the printing of logs in the line or JSON logger like Overrider,
+// but it is able to tell the printer that it failed to ship the message. The context is cancelled
+// when the printer gives up waiting for a flush, long running transports should respect it.
+// The printers reuse msg once Send returns, so a Transport that keeps hold of a message, to batch
+// it or send it later, must copy it.
+type Transport interface {
+	Send(ctx context.Context, msg []byte) error
+}
+
+// TransportFunc lets you use a plain function as a Transport.
+type TransportFunc func(ctx context.Context, msg []byte) error
+
+// Send calls f.
+func (f TransportFunc) Send(ctx context.Context, msg []byte) error {
+	return f(ctx, msg)
+}
+
+// FromOverrider wraps a Overrider so that it can be used where a Transport is needed.
+// The returned Transport never fails.
+func FromOverrider(o Overrider) Transport {
+	return TransportFunc(func(_ context.Context, msg []byte) error {
+		o.Send(string(msg))
+		return nil
+	})
+}
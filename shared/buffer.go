@@ -0,0 +1,79 @@
+package shared
+
+import "sync"
+
+// maxPooledBuffer stops the odd huge message from keeping a huge buffer alive in the pool.
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &Buffer{b: make([]byte, 0, 1024)}
+	},
+}
+
+// Buffer is a reusable byte slice that messages are built in and carried to the printer with.
+// Get one with GetBuffer and give it back with Free once nothing is looking at it any more.
+type Buffer struct {
+	b []byte
+}
+
+// GetBuffer returns an empty Buffer from the pool.
+func GetBuffer() *Buffer {
+	buf := bufferPool.Get().(*Buffer)
+	buf.b = buf.b[:0]
+	return buf
+}
+
+// Free gives the Buffer back to the pool. The Buffer and anything returned by Bytes must not be
+// used afterwards.
+func (b *Buffer) Free() {
+	if cap(b.b) > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(b)
+}
+
+// Bytes returns the contents of the Buffer. The slice is only valid until the Buffer is freed.
+func (b *Buffer) Bytes() []byte {
+	return b.b
+}
+
+// String returns a copy of the contents of the Buffer.
+func (b *Buffer) String() string {
+	return string(b.b)
+}
+
+// Len returns the number of bytes in the Buffer.
+func (b *Buffer) Len() int {
+	return len(b.b)
+}
+
+// Set replaces the contents of the Buffer with p. It is meant for append style functions,
+// buf.Set(strconv.AppendInt(buf.Bytes(), 42, 10)), so the Buffer takes p over rather than
+// copying it.
+func (b *Buffer) Set(p []byte) {
+	b.b = p
+}
+
+// Truncate throws away all but the first n bytes.
+func (b *Buffer) Truncate(n int) {
+	b.b = b.b[:n]
+}
+
+// Write appends p to the Buffer. It never fails.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.b = append(b.b, p...)
+	return len(p), nil
+}
+
+// WriteString appends s to the Buffer. It never fails.
+func (b *Buffer) WriteString(s string) (int, error) {
+	b.b = append(b.b, s...)
+	return len(s), nil
+}
+
+// WriteByte appends c to the Buffer. It never fails.
+func (b *Buffer) WriteByte(c byte) error {
+	b.b = append(b.b, c)
+	return nil
+}
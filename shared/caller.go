@@ -0,0 +1,92 @@
+package shared
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth is the most frames that Stacktrace will write.
+const maxStackDepth = 64
+
+// Caller is the place in the code that a message was logged from.
+type Caller struct {
+	// File is the file name and the directory it is in, server/handler.go.
+	File string
+	// Line is the line number in File.
+	Line int
+	// Function is the function name with its package, server.(*Handler).ServeHTTP.
+	Function string
+}
+
+// String returns the file and line of the caller, server/handler.go:42.
+func (c Caller) String() string {
+	return c.File + ":" + strconv.Itoa(c.Line)
+}
+
+// CallerAt returns the caller skip frames above the function that calls CallerAt, 0 being that
+// function itself. It returns false if the stack is not that deep.
+func CallerAt(skip int) (Caller, bool) {
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return Caller{}, false
+	}
+	caller := Caller{File: trimPath(file), Line: line}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		caller.Function = trimFunction(fn.Name())
+	}
+	return caller, true
+}
+
+// CallerFromPC returns the caller at the program counter pc, like the PC kept in a slog.Record.
+func CallerFromPC(pc uintptr) Caller {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return Caller{File: trimPath(frame.File), Line: frame.Line, Function: trimFunction(frame.Function)}
+}
+
+// Stacktrace returns the stack starting skip frames above the function that calls Stacktrace,
+// 0 being that function itself. Each frame is written like a panic writes them, the function on
+// one line followed by the full path and line number indented on the next.
+func Stacktrace(skip int) string {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	if n == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// trimPath keeps the file name and the directory it is in.
+func trimPath(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i < 0 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
+
+// trimFunction drops the import path in front of the package name.
+func trimFunction(function string) string {
+	if i := strings.LastIndexByte(function, '/'); i >= 0 {
+		return function[i+1:]
+	}
+	return function
+}
@@ -0,0 +1,89 @@
+package shared
+
+import (
+	"os"
+	"sync"
+)
+
+// ANSI escape codes used by the console output of the printers.
+const (
+	ColourReset = "\x1b[0m"
+	ColourDim   = "\x1b[2m"
+	ColourKey   = "\x1b[36m"
+)
+
+// levelColours holds the colour of each level tag.
+var levelColours = map[Level]string{
+	LevelTrace: "\x1b[90m",
+	LevelDebug: "\x1b[35m",
+	LevelInfo:  "\x1b[32m",
+	LevelWarn:  "\x1b[33m",
+	LevelError: "\x1b[31m",
+	LevelCrit:  "\x1b[1;31m",
+	LevelFatal: "\x1b[1;37;41m",
+	LevelPanic: "\x1b[1;37;41m",
+}
+
+// LevelColour returns the ANSI escape code for the colour of level.
+func LevelColour(level Level) string {
+	return levelColours[level]
+}
+
+// ColourMode says when the printers write coloured, human friendly output.
+type ColourMode int
+
+const (
+	// ColourAuto writes in colour when printing to stdout, stdout is a terminal and the NO_COLOR
+	// environment variable is not set. It is the default.
+	ColourAuto ColourMode = iota
+	// ColourAlways always writes in colour, even to overrides and transports.
+	ColourAlways
+	// ColourNever never writes in colour.
+	ColourNever
+)
+
+var (
+	colourStdoutOnce sync.Once
+	colourStdout     bool
+)
+
+// ColourStdout tells you if coloured output to stdout would be seen by a person. It is false if
+// stdout is piped or redirected to a file, or the NO_COLOR environment variable is set to
+// anything, see https://no-color.org. It is worked out once and remembered.
+func ColourStdout() bool {
+	colourStdoutOnce.Do(func() {
+		if os.Getenv("NO_COLOR") != "" {
+			return
+		}
+		colourStdout = IsTerminal(os.Stdout)
+	})
+	return colourStdout
+}
+
+// IsTerminal tells you if f is a terminal. It asks the terminal driver on Linux, the BSDs, macOS
+// and Windows, so character devices such as /dev/null are not mistaken for terminals. On other
+// platforms any character device is taken to be a terminal.
+func IsTerminal(f *os.File) bool {
+	return isTerminal(f)
+}
+
+// isCharDevice is the fallback for platforms where we can not ask the terminal driver.
+func isCharDevice(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Enabled tells you if output should be coloured. toStdout is true if the output is going to
+// stdout rather than an override or transport.
+func (m ColourMode) Enabled(toStdout bool) bool {
+	switch m {
+	case ColourAlways:
+		return true
+	case ColourNever:
+		return false
+	}
+	return toStdout && ColourStdout()
+}
@@ -0,0 +1,68 @@
+package shared
+
+import "context"
+
+// FlushReport tells you what was left behind when a flush stopped waiting for the printer
+// to finish.
+type FlushReport struct {
+	// Buffered is the number of messages that were still in the buffer.
+	Buffered int
+	// InFlight is the number of messages that the output was still busy with.
+	InFlight int
+	// Recovered is the number of buffered messages that were handed to the fallback printer.
+	Recovered int
+}
+
+// Unflushed is the number of messages that did not make it to the output.
+func (r FlushReport) Unflushed() int {
+	return r.Buffered + r.InFlight
+}
+
+// Merge adds the counters in other to r and returns the result.
+func (r FlushReport) Merge(other FlushReport) FlushReport {
+	return FlushReport{
+		Buffered:  r.Buffered + other.Buffered,
+		InFlight:  r.InFlight + other.InFlight,
+		Recovered: r.Recovered + other.Recovered,
+	}
+}
+
+// WaitForFlush waits for finished to fire or ctx to expire. If ctx expires first the messages
+// left in pipe are taken out and given to fallback, unless fallback is nil in which case they are
+// only counted. inFlight is the number of messages the output is busy with.
+// The returned error is ctx.Err() if the flush did not finish in time.
+func WaitForFlush(ctx context.Context, finished chan bool, pipe chan Entry, inFlight func() int, fallback func(string)) (FlushReport, error) {
+	select {
+	case <-finished:
+		return FlushReport{}, nil
+	case <-ctx.Done():
+	}
+
+	report := FlushReport{
+		InFlight: inFlight(),
+	}
+
+	if fallback == nil {
+		report.Buffered = len(pipe)
+		return report, ctx.Err()
+	}
+
+	for {
+		select {
+		case entry, ok := <-pipe:
+			if !ok {
+				return report, ctx.Err()
+			}
+			if entry.Synced != nil {
+				close(entry.Synced)
+				continue
+			}
+			fallback(entry.Message.String())
+			entry.Message.Free()
+			report.Buffered++
+			report.Recovered++
+		default:
+			return report, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package shared
+
+import "sync/atomic"
+
+// Level is the concern level of a message. Levels are ordered so they can be compared,
+// LevelTrace < LevelDebug < LevelInfo < LevelWarn < LevelError < LevelCrit < LevelFatal < LevelPanic.
+type Level int
+
+const (
+	// LevelTrace is the level of trace messages, even more detailed than debug.
+	LevelTrace Level = iota
+	// LevelDebug is the level of debug messages.
+	LevelDebug
+	// LevelInfo is the level of informational messages.
+	LevelInfo
+	// LevelWarn is the level of warning messages.
+	LevelWarn
+	// LevelError is the level of error messages that the program can recover from.
+	LevelError
+	// LevelCrit is the level of critical messages.
+	LevelCrit
+	// LevelFatal is the level of messages logged just before the program exits.
+	LevelFatal
+	// LevelPanic is the level of messages logged just before the program panics.
+	LevelPanic
+)
+
+var levelNames = map[Level]string{
+	LevelTrace: TraceMessage,
+	LevelDebug: DebugMessage,
+	LevelInfo:  InformationMessage,
+	LevelWarn:  WarningMessage,
+	LevelError: ErrorMessage,
+	LevelCrit:  CriticalMessage,
+	LevelFatal: FatalMessage,
+	LevelPanic: PanicMessage,
+}
+
+// String returns the hint used for the level in log messages, eg. INFO.
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// ParseLevel turns a hint such as INFO back into a Level. The bool is false if the hint is
+// not a known level.
+func ParseLevel(hint string) (Level, bool) {
+	for level, name := range levelNames {
+		if name == hint {
+			return level, true
+		}
+	}
+	return LevelInfo, false
+}
+
+// LevelOverride holds a level that can be changed at runtime and read from any goroutine.
+// The zero value holds no level, so whoever owns it falls back to the level it would otherwise use.
+type LevelOverride struct {
+	// level is stored one higher than the level so that zero means not set.
+	level int32
+}
+
+// Set stores the level.
+func (o *LevelOverride) Set(level Level) {
+	atomic.StoreInt32(&o.level, int32(level)+1)
+}
+
+// Reset removes the stored level.
+func (o *LevelOverride) Reset() {
+	atomic.StoreInt32(&o.level, 0)
+}
+
+// Get returns the stored level. The bool is false if no level is stored.
+func (o *LevelOverride) Get() (Level, bool) {
+	stored := atomic.LoadInt32(&o.level)
+	if stored == 0 {
+		return LevelInfo, false
+	}
+	return Level(stored - 1), true
+}
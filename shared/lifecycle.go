@@ -0,0 +1,62 @@
+package shared
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	stateRunning int32 = iota
+	stateShutdown
+)
+
+// Lifecycle guards the buffer of a printer while it is being shut down. Senders must call Enter
+// before they put a message into the buffer and Leave once they are done with it. Shutdown will
+// only close the buffer once every sender that got in has left, so a message is either delivered
+// or refused but the buffer is never written to once closed.
+// The zero value is a running printer.
+type Lifecycle struct {
+	// inflight is kept first so that it is 64 bit aligned for atomic access.
+	inflight int64
+	state    int32
+	once     sync.Once
+}
+
+// Enter tells the Lifecycle that a sender wants to use the buffer. If it returns true the sender
+// may use the buffer and MUST call Leave when finished. If it returns false the printer is shutting
+// down and the buffer must not be used.
+func (l *Lifecycle) Enter() bool {
+	atomic.AddInt64(&l.inflight, 1)
+	if atomic.LoadInt32(&l.state) != stateRunning {
+		l.Leave()
+		return false
+	}
+	return true
+}
+
+// Leave tells the Lifecycle that a sender has finished with the buffer.
+func (l *Lifecycle) Leave() {
+	atomic.AddInt64(&l.inflight, -1)
+}
+
+// IsShutdown tells you if Shutdown has been called.
+func (l *Lifecycle) IsShutdown() bool {
+	return atomic.LoadInt32(&l.state) != stateRunning
+}
+
+// Shutdown stops any more senders from entering and calls closer once all the senders that have
+// already entered have left. Only the first call does anything, the rest return straight away.
+// closer is called from its own goroutine as senders in audit mode may be waiting for space in
+// the buffer.
+func (l *Lifecycle) Shutdown(closer func()) {
+	l.once.Do(func() {
+		atomic.StoreInt32(&l.state, stateShutdown)
+		go func() {
+			for atomic.LoadInt64(&l.inflight) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+			closer()
+		}()
+	})
+}
@@ -1,16 +1,48 @@
 package shared
 
 const (
+	// TraceMessage is the hint for trace level messages.
+	TraceMessage = "TRACE"
 	// InformationMessage is the hint for informational messages.
 	InformationMessage = "INFO"
 	// WarningMessage is the hint for warning messages.
 	WarningMessage = "WARN"
+	// ErrorMessage is the hint for error level messages.
+	ErrorMessage = "ERROR"
 	// CriticalMessage is the hint for critical level messages.
 	CriticalMessage = "CRIT"
+	// FatalMessage is the hint for messages logged just before the program exits.
+	FatalMessage = "FATAL"
+	// PanicMessage is the hint for messages logged just before the program panics.
+	PanicMessage = "PANIC"
 	// DebugMessage is the hint for debug level messages.
 	DebugMessage = "DEBUG"
 )
 
+// Entry is a message waiting in a printers buffer along with its level. Whoever takes the
+// Entry out of the buffer must free the Message once it has been printed.
+// Entries put in the buffer by Sync have no Message, only Synced which must be closed instead.
+type Entry struct {
+	Level   Level
+	Message *Buffer
+	Synced  chan bool
+}
+
+// Sync puts a marker into pipe behind the messages already in it and returns a channel that the
+// printer closes when it gets to the marker, so once every message sent before Sync has been
+// printed. The printer keeps running. If the printer is shutting down finished is returned
+// instead, which fires once the buffer is empty.
+func Sync(lifecycle *Lifecycle, pipe chan Entry, finished chan bool) chan bool {
+	if !lifecycle.Enter() {
+		return finished
+	}
+	defer lifecycle.Leave()
+
+	synced := make(chan bool)
+	pipe <- Entry{Synced: synced}
+	return synced
+}
+
 // AuditSender is not able to drop messages, it will therefore slow down your
 // application in order to ship logs. This can have undesirable effects on your application,
 // however if logs are more important than service then this is the only option.
@@ -31,3 +63,22 @@ func BestEffortSender(msg string, pipe chan string, droppedFunc func()) {
 		droppedFunc()
 	}
 }
+
+// AuditEntrySender is AuditSender for the Entry buffers that the printers use.
+func AuditEntrySender(msg Entry, pipe chan Entry) {
+	pipe <- msg
+}
+
+// BestEffortEntrySender is BestEffortSender for the Entry buffers that the printers use.
+// The returned bool tells you if the message made it into the buffer. The Message of a
+// dropped Entry is freed.
+func BestEffortEntrySender(msg Entry, pipe chan Entry, droppedFunc func()) bool {
+	select {
+	case pipe <- msg:
+		return true
+	default:
+		msg.Message.Free()
+		droppedFunc()
+		return false
+	}
+}
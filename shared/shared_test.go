@@ -1,6 +1,9 @@
 package shared
 
 import (
+	"context"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -46,3 +49,301 @@ func TestAuditSender(t *testing.T) {
 	case <-ticker.C:
 	}
 }
+
+func TestBestEffortEntrySender(t *testing.T) {
+	c := make(chan Entry, 1)
+	dropped := 0
+	droppedFunc := func() {
+		dropped++
+	}
+
+	// Send messages
+	BestEffortEntrySender(testEntry("1"), c, droppedFunc)
+	// It should be full now.
+	BestEffortEntrySender(testEntry("2"), c, droppedFunc)
+
+	if dropped == 0 {
+		t.Logf("BestEffortSender did not drop messages when the queue is full.")
+		t.Fail()
+	}
+}
+
+func TestAuditEntrySender(t *testing.T) {
+	c := make(chan Entry, 1)
+
+	// Send first message. It should accept this one.
+	AuditEntrySender(testEntry("1"), c)
+	// Now its is full, so we need to send and timeout
+	auditSenderWaiter := func() chan bool {
+		senderChan := make(chan bool, 1)
+		go func() {
+			AuditEntrySender(testEntry("2"), c)
+			senderChan <- true
+		}()
+		return senderChan
+	}
+	ticker := time.NewTicker(time.Millisecond * 2)
+
+	select {
+	case <-auditSenderWaiter():
+		t.Logf("AuditSender return when the channel was full.")
+		t.FailNow()
+	case <-ticker.C:
+	}
+}
+
+func TestCounters(t *testing.T) {
+	c := &Counters{}
+	c.Accepted()
+	c.Accepted()
+	c.Dropped()
+	c.MutatorRejected()
+	c.DebugFiltered()
+	c.Filtered(LevelDebug)
+	c.Filtered(LevelWarn)
+	c.Written(10)
+	c.TransportFailed()
+
+	want := Stats{
+		Accepted:          2,
+		Dropped:           1,
+		MutatorRejected:   1,
+		DebugFiltered:     2,
+		LevelFiltered:     1,
+		BytesWritten:      10,
+		TransportFailures: 1,
+		BufferDepth:       3,
+	}
+	if got := c.Snapshot(3); got != want {
+		t.Logf("Counters snapshot is wrong. Got: %+v, Want: %+v", got, want)
+		t.Fail()
+	}
+
+	if got := want.Merge(want); got.Accepted != 4 || got.BufferDepth != 6 {
+		t.Logf("Merging stats did not add the counters together. Got: %+v", got)
+		t.Fail()
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	l := &Lifecycle{}
+
+	if !l.Enter() {
+		t.Logf("A new Lifecycle refused a sender.")
+		t.FailNow()
+	}
+
+	closed := make(chan bool)
+	l.Shutdown(func() { close(closed) })
+	// A second call must not call closer again, that would panic on the closed channel.
+	l.Shutdown(func() { close(closed) })
+
+	if l.Enter() {
+		t.Logf("Lifecycle let a sender in after being shut down.")
+		t.Fail()
+	}
+
+	select {
+	case <-closed:
+		t.Logf("Lifecycle called closer while a sender was still using the buffer.")
+		t.FailNow()
+	case <-time.After(time.Millisecond * 5):
+	}
+
+	l.Leave()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Logf("Lifecycle did not call closer once the last sender left.")
+		t.Fail()
+	}
+}
+
+func TestWaitForFlush(t *testing.T) {
+	pipe := make(chan Entry, 5)
+	pipe <- testEntry("1")
+	pipe <- testEntry("2")
+	inFlight := func() int { return 1 }
+
+	finished := make(chan bool)
+	close(finished)
+	report, err := WaitForFlush(context.Background(), finished, pipe, inFlight, nil)
+	if err != nil || report.Unflushed() != 0 {
+		t.Logf("A finished flush reported left overs. Report: %+v, Error: %v", report, err)
+		t.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err = WaitForFlush(ctx, make(chan bool), pipe, inFlight, nil)
+	if err != context.Canceled || report.Buffered != 2 || report.InFlight != 1 || report.Recovered != 0 {
+		t.Logf("An expired flush without a fallback reported the wrong thing. Report: %+v, Error: %v", report, err)
+		t.Fail()
+	}
+
+	recovered := []string{}
+	report, err = WaitForFlush(ctx, make(chan bool), pipe, inFlight, func(msg string) { recovered = append(recovered, msg) })
+	if err != context.Canceled || report.Buffered != 2 || report.Recovered != 2 || len(recovered) != 2 {
+		t.Logf("An expired flush with a fallback did not recover the messages. Report: %+v, Recovered: %v", report, recovered)
+		t.Fail()
+	}
+}
+
+func TestLevels(t *testing.T) {
+	ordered := []Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelCrit, LevelFatal, LevelPanic}
+	for i := 1; i < len(ordered); i++ {
+		if ordered[i-1] >= ordered[i] {
+			t.Logf("Levels are not in order, %s should be below %s.", ordered[i-1], ordered[i])
+			t.Fail()
+		}
+	}
+
+	for _, level := range ordered {
+		parsed, ok := ParseLevel(level.String())
+		if !ok || parsed != level {
+			t.Logf("Level %s did not survive being parsed. Got: %s", level, parsed)
+			t.Fail()
+		}
+	}
+
+	if _, ok := ParseLevel("NOT_A_LEVEL"); ok {
+		t.Logf("ParseLevel accepted a level that does not exist.")
+		t.Fail()
+	}
+}
+
+func TestLevelOverride(t *testing.T) {
+	override := LevelOverride{}
+	if _, ok := override.Get(); ok {
+		t.Logf("Zero value LevelOverride should not hold a level.")
+		t.Fail()
+	}
+
+	override.Set(LevelTrace)
+	if level, ok := override.Get(); !ok || level != LevelTrace {
+		t.Logf("LevelOverride did not keep the level. Got: %s, %v", level, ok)
+		t.Fail()
+	}
+
+	override.Reset()
+	if _, ok := override.Get(); ok {
+		t.Logf("Reset LevelOverride should not hold a level.")
+		t.Fail()
+	}
+}
+
+func testEntry(msg string) Entry {
+	buf := GetBuffer()
+	buf.WriteString(msg)
+	return Entry{Level: LevelInfo, Message: buf}
+}
+
+func TestBuffer(t *testing.T) {
+	buf := GetBuffer()
+	buf.WriteString("level=")
+	buf.Set(strconv.AppendInt(buf.Bytes(), 42, 10))
+	buf.WriteByte(' ')
+	buf.Write([]byte("done"))
+	if buf.String() != "level=42 done" || buf.Len() != 13 {
+		t.Logf("Buffer did not collect the writes. Got: %q", buf.String())
+		t.Fail()
+	}
+	buf.Truncate(8)
+	if buf.String() != "level=42" {
+		t.Logf("Buffer was not truncated. Got: %q", buf.String())
+		t.Fail()
+	}
+	buf.Free()
+
+	if reused := GetBuffer(); reused.Len() != 0 {
+		t.Logf("Buffer from the pool is not empty. Got: %q", reused.String())
+		t.Fail()
+	}
+}
+
+func TestCallerAt(t *testing.T) {
+	caller, ok := CallerAt(0)
+	if !ok {
+		t.Fatalf("CallerAt could not find the caller.")
+	}
+	if caller.File != "shared/shared_test.go" || caller.Function != "shared.TestCallerAt" || caller.Line == 0 {
+		t.Logf("CallerAt found the wrong caller. Got: %+v", caller)
+		t.Fail()
+	}
+	if want := "shared/shared_test.go:" + strconv.Itoa(caller.Line); caller.String() != want {
+		t.Logf("Caller string is wrong. Got: %s, Want: %s", caller.String(), want)
+		t.Fail()
+	}
+
+	if _, ok := CallerAt(1000); ok {
+		t.Logf("CallerAt found a caller above the top of the stack.")
+		t.Fail()
+	}
+}
+
+func TestStacktrace(t *testing.T) {
+	trace := Stacktrace(0)
+	if !strings.HasPrefix(trace, "github.com/silverstagtech/loggos/shared.TestStacktrace\n\t") {
+		t.Logf("Stack trace does not start with the caller. Got: %s", trace)
+		t.Fail()
+	}
+	if !strings.Contains(trace, "shared_test.go:") {
+		t.Logf("Stack trace is missing the file. Got: %s", trace)
+		t.Fail()
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	type line struct {
+		level Level
+		msg   string
+	}
+	got := []line{}
+	w := NewLineWriter(LevelInfo, func(level Level, msg string) {
+		got = append(got, line{level: level, msg: msg})
+	})
+
+	w.Write([]byte("first\nsec"))
+	w.Write([]byte("ond\r\n\n[WARN] not detected\n"))
+	w.DetectLevels(true)
+	w.Write([]byte("[warning] detected\n  [ERROR]  detected\n[NOPE] unknown\nunfinished"))
+	w.Write([]byte(strings.Repeat("x", maxLineLength)))
+	w.Flush()
+	w.Flush()
+
+	expected := []line{
+		{level: LevelInfo, msg: "first"},
+		{level: LevelInfo, msg: "second"},
+		{level: LevelInfo, msg: "[WARN] not detected"},
+		{level: LevelWarn, msg: "detected"},
+		{level: LevelError, msg: "detected"},
+		{level: LevelInfo, msg: "[NOPE] unknown"},
+		{level: LevelInfo, msg: "unfinished" + strings.Repeat("x", maxLineLength)},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Wanted %d lines. Got: %v", len(expected), got)
+	}
+	for i, want := range expected {
+		if got[i] != want {
+			t.Logf("Line %d is wrong. Got: %v %q, Want: %v %q", i, got[i].level, got[i].msg, want.level, want.msg)
+			t.Fail()
+		}
+	}
+}
+
+func TestColourMode(t *testing.T) {
+	if !ColourAlways.Enabled(false) || ColourNever.Enabled(true) || ColourAuto.Enabled(false) {
+		t.Logf("Colour modes are not honoured.")
+		t.Fail()
+	}
+	if ColourAuto.Enabled(true) != ColourStdout() {
+		t.Logf("ColourAuto does not follow stdout.")
+		t.Fail()
+	}
+	if LevelColour(LevelWarn) == "" || LevelColour(LevelWarn) == LevelColour(LevelError) {
+		t.Logf("Levels don't have their own colours.")
+		t.Fail()
+	}
+}
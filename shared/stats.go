@@ -0,0 +1,106 @@
+package shared
+
+import "sync/atomic"
+
+// Stats is a point in time snapshot of what a printer has done with the messages that it
+// has been given.
+type Stats struct {
+	// Accepted is the number of messages that made it into the printers buffer.
+	Accepted int64
+	// Dropped is the number of messages that were lost because the buffer was full.
+	Dropped int64
+	// MutatorRejected is the number of messages that a mutator refused to let through.
+	MutatorRejected int64
+	// DebugFiltered is the number of debug messages that were thrown away because debug
+	// logging is turned off.
+	DebugFiltered int64
+	// LevelFiltered is the number of messages, other than debug messages, that were thrown
+	// away because they are below the level of the printer.
+	LevelFiltered int64
+	// BytesWritten is the number of bytes handed to the output.
+	BytesWritten int64
+	// TransportFailures is the number of messages that the output reported it failed to ship.
+	TransportFailures int64
+	// BufferDepth is the number of messages waiting in the buffer when the snapshot was taken.
+	BufferDepth int64
+}
+
+// Merge adds the counters in other to s and returns the result.
+func (s Stats) Merge(other Stats) Stats {
+	return Stats{
+		Accepted:          s.Accepted + other.Accepted,
+		Dropped:           s.Dropped + other.Dropped,
+		MutatorRejected:   s.MutatorRejected + other.MutatorRejected,
+		DebugFiltered:     s.DebugFiltered + other.DebugFiltered,
+		LevelFiltered:     s.LevelFiltered + other.LevelFiltered,
+		BytesWritten:      s.BytesWritten + other.BytesWritten,
+		TransportFailures: s.TransportFailures + other.TransportFailures,
+		BufferDepth:       s.BufferDepth + other.BufferDepth,
+	}
+}
+
+// Counters holds the live counters behind Stats. All methods use atomic operations so they
+// can be called from any goroutine. The zero value is ready to use.
+type Counters struct {
+	accepted        int64
+	dropped         int64
+	mutatorRejected int64
+	debugFiltered   int64
+	levelFiltered   int64
+	bytesWritten    int64
+	transportFailed int64
+}
+
+// Accepted records a message going into the buffer.
+func (c *Counters) Accepted() {
+	atomic.AddInt64(&c.accepted, 1)
+}
+
+// Dropped records a message that was lost because the buffer was full.
+func (c *Counters) Dropped() {
+	atomic.AddInt64(&c.dropped, 1)
+}
+
+// MutatorRejected records a message that was discarded by a mutator.
+func (c *Counters) MutatorRejected() {
+	atomic.AddInt64(&c.mutatorRejected, 1)
+}
+
+// DebugFiltered records a debug message that was not printed.
+func (c *Counters) DebugFiltered() {
+	atomic.AddInt64(&c.debugFiltered, 1)
+}
+
+// Filtered records a message that was not printed because it is below the level of the printer.
+func (c *Counters) Filtered(level Level) {
+	if level == LevelDebug {
+		c.DebugFiltered()
+		return
+	}
+	atomic.AddInt64(&c.levelFiltered, 1)
+}
+
+// Written records n bytes being handed to the output.
+func (c *Counters) Written(n int) {
+	atomic.AddInt64(&c.bytesWritten, int64(n))
+}
+
+// TransportFailed records a message that the output failed to ship.
+func (c *Counters) TransportFailed() {
+	atomic.AddInt64(&c.transportFailed, 1)
+}
+
+// Snapshot returns the current value of the counters. bufferDepth is passed in by the
+// printer as only it knows about its buffer.
+func (c *Counters) Snapshot(bufferDepth int) Stats {
+	return Stats{
+		Accepted:          atomic.LoadInt64(&c.accepted),
+		Dropped:           atomic.LoadInt64(&c.dropped),
+		MutatorRejected:   atomic.LoadInt64(&c.mutatorRejected),
+		DebugFiltered:     atomic.LoadInt64(&c.debugFiltered),
+		LevelFiltered:     atomic.LoadInt64(&c.levelFiltered),
+		BytesWritten:      atomic.LoadInt64(&c.bytesWritten),
+		TransportFailures: atomic.LoadInt64(&c.transportFailed),
+		BufferDepth:       int64(bufferDepth),
+	}
+}
@@ -0,0 +1,14 @@
+package shared
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isTerminal asks for the terminal settings of f, which only a terminal has.
+func isTerminal(f *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
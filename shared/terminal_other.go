@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package shared
+
+import "os"
+
+func isTerminal(f *os.File) bool {
+	return isCharDevice(f)
+}
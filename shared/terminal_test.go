@@ -0,0 +1,36 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package shared
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestIsTerminal(t *testing.T) {
+	// /dev/null is a character device but not a terminal.
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Logf("Failed to open %s. Error: %s", os.DevNull, err)
+		t.FailNow()
+	}
+	defer null.Close()
+	if IsTerminal(null) {
+		t.Logf("%s was taken to be a terminal.", os.DevNull)
+		t.Fail()
+	}
+
+	file, err := ioutil.TempFile("", "loggos-terminal")
+	if err != nil {
+		t.Logf("Failed to make a temp file. Error: %s", err)
+		t.FailNow()
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if IsTerminal(file) {
+		t.Logf("A regular file was taken to be a terminal.")
+		t.Fail()
+	}
+}
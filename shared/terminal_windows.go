@@ -0,0 +1,12 @@
+package shared
+
+import (
+	"os"
+	"syscall"
+)
+
+// isTerminal asks for the console mode of f, which only a console has.
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
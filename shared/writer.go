@@ -0,0 +1,103 @@
+package shared
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// maxLineLength is the longest line that LineWriter will wait for the end of. Longer lines are
+// logged in pieces so that a writer that never sends a new line can't use up all the memory.
+const maxLineLength = 64 << 10
+
+// LineWriter is an io.Writer that splits what is written to it into lines and logs each one.
+// It lets libraries that write to an io.Writer or a *log.Logger log through loggos. Anything
+// after the last new line is held until the rest of the line arrives or Flush is called.
+type LineWriter struct {
+	lock    sync.Mutex
+	level   Level
+	detect  bool
+	log     func(Level, string)
+	partial []byte
+}
+
+// NewLineWriter returns a LineWriter that passes each line to log at level.
+func NewLineWriter(level Level, log func(Level, string)) *LineWriter {
+	return &LineWriter{level: level, log: log}
+}
+
+// DetectLevels makes the writer look for a level in square brackets at the start of each line,
+// like [WARN] or [error]. Lines that have one are logged at that level with it taken off,
+// lines without one are logged at the level of the writer.
+func (w *LineWriter) DetectLevels(toggle bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.detect = toggle
+}
+
+// Write logs every complete line in p. It never fails.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	data := p
+	if len(w.partial) > 0 {
+		w.partial = append(w.partial, p...)
+		data = w.partial
+	}
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		w.logLine(data[:i])
+		data = data[i+1:]
+	}
+	if len(data) >= maxLineLength {
+		w.logLine(data)
+		data = nil
+	}
+	// Keep what is left in our own slice, p belongs to the caller.
+	w.partial = append(w.partial[:0], data...)
+	return len(p), nil
+}
+
+// Flush logs anything that has been written without a new line at the end.
+func (w *LineWriter) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.partial) > 0 {
+		w.logLine(w.partial)
+		w.partial = w.partial[:0]
+	}
+}
+
+func (w *LineWriter) logLine(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if len(line) == 0 {
+		return
+	}
+	level, msg := w.level, string(line)
+	if w.detect {
+		level, msg = detectLevel(w.level, msg)
+	}
+	w.log(level, msg)
+}
+
+// detectLevel takes a level in square brackets off the start of line.
+func detectLevel(fallback Level, line string) (Level, string) {
+	trimmed := strings.TrimLeft(line, " \t")
+	end := strings.IndexByte(trimmed, ']')
+	if !strings.HasPrefix(trimmed, "[") || end < 0 {
+		return fallback, line
+	}
+	name := strings.ToUpper(trimmed[1:end])
+	if name == "WARNING" {
+		name = WarningMessage
+	}
+	level, ok := ParseLevel(name)
+	if !ok {
+		return fallback, line
+	}
+	return level, strings.TrimLeft(trimmed[end+1:], " \t")
+}
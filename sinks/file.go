@@ -0,0 +1,327 @@
+package sinks
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// rotatedTimeFormat is used to name rotated files. It sorts in time order.
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// FileOptions controls when a File rotates and what it keeps.
+type FileOptions struct {
+	// MaxSize is the size in bytes a file can grow to before it is rotated. Zero means no limit.
+	MaxSize int64
+	// RotateEvery rotates the file once it has been open this long. Zero means never.
+	RotateEvery time.Duration
+	// MaxFiles is the number of rotated files to keep. Zero keeps them all.
+	MaxFiles int
+	// Compress gzips rotated files.
+	Compress bool
+	// OnRotateError is called when moving, compressing or pruning files fails. The file at path
+	// is opened again either way so messages carry on being written. Errors are printed to
+	// stderr if it is nil.
+	OnRotateError func(error)
+}
+
+// File is a Transport that appends messages to a file, one per line, rotating the file by size
+// and age. Rotated files are renamed to <path>.<timestamp>, with .gz added if they are compressed.
+// Flushing a File syncs it to disk so that audit mode logs really make it there.
+type File struct {
+	lock     sync.Mutex
+	path     string
+	options  FileOptions
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	signals  chan os.Signal
+	closed   bool
+	now      func() time.Time
+}
+
+// NewFile opens, or creates, the file at path ready for messages.
+func NewFile(path string, options FileOptions) (*File, error) {
+	f := &File{
+		path:    path,
+		options: options,
+		now:     time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Send writes msg to the file followed by a new line if it does not already end with one.
+// The file is rotated first if it is due.
+func (f *File) Send(_ context.Context, msg []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	// Opening the file after the last rotation failed, try again.
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	length := int64(len(msg))
+	newLine := len(msg) == 0 || msg[len(msg)-1] != '\n'
+	if newLine {
+		length++
+	}
+
+	if f.dueForRotation(length) {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(msg)
+	f.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if newLine {
+		n, err = f.file.Write([]byte{'\n'})
+		f.size += int64(n)
+	}
+	return err
+}
+
+// Flush syncs the file to disk.
+func (f *File) Flush(context.Context) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Reopen closes the file and opens the file at path again. This is what logrotate expects
+// after it has moved the file out of the way. It does nothing once the File has been closed.
+func (f *File) Reopen() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	return f.open()
+}
+
+// ReopenOnSignal calls Reopen every time one of the signals arrives. SIGHUP is used if no
+// signals are given. Close stops listening for the signals.
+func (f *File) ReopenOnSignal(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.closed {
+		return
+	}
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.signals)
+	}
+
+	f.signals = make(chan os.Signal, 1)
+	signal.Notify(f.signals, signals...)
+	go func(c chan os.Signal) {
+		for range c {
+			f.Reopen()
+		}
+	}(f.signals)
+}
+
+// Close syncs and closes the file. Messages sent after Close fail with os.ErrClosed.
+func (f *File) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.signals)
+		f.signals = nil
+	}
+
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+
+	f.file.Sync()
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+func (f *File) dueForRotation(length int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.options.MaxSize > 0 && f.size+length > f.options.MaxSize {
+		return true
+	}
+	if f.options.RotateEvery > 0 && f.now().Sub(f.openedAt) >= f.options.RotateEvery {
+		return true
+	}
+	return false
+}
+
+// rotate moves the file out of the way and opens a new one at path. The new file is opened even
+// if moving, compressing or pruning fails so that one bad rotation does not stop logging. Those
+// errors go to OnRotateError, only failing to open the new file is returned.
+func (f *File) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = f.archive()
+	}
+	if err != nil {
+		f.rotateError(err)
+	}
+	return f.open()
+}
+
+// archive renames the closed file, compresses it and prunes the old files.
+func (f *File) archive() error {
+	rotated := f.path + "." + f.now().Format(rotatedTimeFormat)
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+
+	if f.options.Compress {
+		if err := compress(rotated); err != nil {
+			return err
+		}
+	}
+
+	return f.prune()
+}
+
+func (f *File) rotateError(err error) {
+	if f.options.OnRotateError != nil {
+		f.options.OnRotateError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "file sink: failed to rotate %s: %s\n", f.path, err)
+}
+
+// prune removes the oldest rotated files until only MaxFiles are left. Only files named like
+// rotated files are counted, anything else next to the file is left alone.
+func (f *File) prune() error {
+	if f.options.MaxFiles <= 0 {
+		return nil
+	}
+
+	rotated, err := f.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	sort.Strings(rotated)
+
+	for len(rotated) > f.options.MaxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			return err
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// rotatedFiles returns the paths of the files made by rotating this file, those named
+// <path>.<timestamp> with an optional .gz.
+func (f *File) rotatedFiles() ([]string, error) {
+	dir, base := filepath.Split(f.path)
+	if dir == "" {
+		dir = "."
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := base + "."
+	var rotated []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		if _, err := time.Parse(rotatedTimeFormat, stamp); err != nil {
+			continue
+		}
+		rotated = append(rotated, filepath.Join(dir, name))
+	}
+	return rotated, nil
+}
+
+// compress gzips the file at path into path.gz and removes the original.
+func compress(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		in.Close()
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	in.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,210 @@
+package sinks
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/silverstagtech/loggos/lineprinter"
+)
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Logf("Failed to read %s. Error: %s", path, err)
+		t.FailNow()
+	}
+	return string(b)
+}
+
+func TestFileWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, err := NewFile(path, FileOptions{})
+	if err != nil {
+		t.Logf("Failed to create the file sink. Error: %s", err)
+		t.FailNow()
+	}
+
+	f.Send(context.Background(), []byte("one"))
+	f.Send(context.Background(), []byte("two\n"))
+	f.Close()
+
+	if got := readFile(t, path); got != "one\ntwo\n" {
+		t.Logf("File did not get one message per line. Got: %q", got)
+		t.Fail()
+	}
+
+	if err := f.Send(context.Background(), []byte("closed")); err == nil {
+		t.Logf("Sending to a closed file did not fail.")
+		t.Fail()
+	}
+}
+
+func TestFileRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	f, _ := NewFile(path, FileOptions{MaxSize: 10, MaxFiles: 2})
+
+	now := time.Now()
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, msg := range []string{"message 1", "message 2", "message 3", "message 4"} {
+		f.Send(context.Background(), []byte(msg))
+	}
+	f.Close()
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) != 2 {
+		t.Logf("Wanted 2 rotated files to be kept but got %v", rotated)
+		t.Fail()
+	}
+	if got := readFile(t, path); got != "message 4\n" {
+		t.Logf("Current file has the wrong content. Got: %q", got)
+		t.Fail()
+	}
+	if got := readFile(t, rotated[0]); got != "message 2\n" {
+		t.Logf("The oldest rotated file was not removed. Got: %q", got)
+		t.Fail()
+	}
+}
+
+func TestFileRotatesByTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, _ := NewFile(path, FileOptions{RotateEvery: time.Hour, Compress: true})
+
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	f.Send(context.Background(), []byte("first hour"))
+	now = now.Add(time.Hour)
+	f.Send(context.Background(), []byte("second hour"))
+	f.Close()
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) != 1 || !strings.HasSuffix(rotated[0], ".gz") {
+		t.Logf("Wanted 1 compressed rotated file but got %v", rotated)
+		t.FailNow()
+	}
+
+	gzFile, _ := os.Open(rotated[0])
+	defer gzFile.Close()
+	gz, err := gzip.NewReader(gzFile)
+	if err != nil {
+		t.Logf("Rotated file is not gzipped. Error: %s", err)
+		t.FailNow()
+	}
+	b, _ := ioutil.ReadAll(gz)
+	if string(b) != "first hour\n" {
+		t.Logf("Rotated file has the wrong content. Got: %q", b)
+		t.Fail()
+	}
+}
+
+func TestFilePruneKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	for _, other := range []string{".bak", ".old", ".1"} {
+		ioutil.WriteFile(path+other, []byte("keep"), 0644)
+	}
+	f, _ := NewFile(path, FileOptions{MaxSize: 10, MaxFiles: 1})
+
+	now := time.Now()
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, msg := range []string{"message 1", "message 2", "message 3"} {
+		f.Send(context.Background(), []byte(msg))
+	}
+	f.Close()
+
+	for _, other := range []string{".bak", ".old", ".1"} {
+		if _, err := os.Stat(path + other); err != nil {
+			t.Logf("Pruning removed a file that was not rotated. Error: %s", err)
+			t.Fail()
+		}
+	}
+	rotated, _ := f.rotatedFiles()
+	if len(rotated) != 1 {
+		t.Logf("Wanted 1 rotated file to be kept but got %v", rotated)
+		t.Fail()
+	}
+}
+
+func TestFileKeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	var rotateErrors []error
+	f, _ := NewFile(path, FileOptions{
+		MaxSize:       10,
+		OnRotateError: func(err error) { rotateErrors = append(rotateErrors, err) },
+	})
+
+	now := time.Now()
+	f.now = func() time.Time { return now }
+	// A directory in the way of the rotated file makes the rename fail.
+	blocker := path + "." + now.Format(rotatedTimeFormat)
+	os.MkdirAll(filepath.Join(blocker, "full"), 0755)
+
+	for _, msg := range []string{"message 1", "message 2"} {
+		if err := f.Send(context.Background(), []byte(msg)); err != nil {
+			t.Logf("Send failed after a bad rotation. Error: %s", err)
+			t.Fail()
+		}
+	}
+	f.Close()
+
+	if len(rotateErrors) != 1 {
+		t.Logf("Wanted 1 rotation error but got %v", rotateErrors)
+		t.Fail()
+	}
+	if got := readFile(t, path); got != "message 1\nmessage 2\n" {
+		t.Logf("Messages were lost after a bad rotation. Got: %q", got)
+		t.Fail()
+	}
+}
+
+func TestFileReopenAfterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, _ := NewFile(path, FileOptions{})
+	f.ReopenOnSignal()
+	f.Close()
+	os.Remove(path)
+
+	if err := f.Reopen(); err == nil {
+		t.Logf("Reopen after Close did not fail.")
+		t.Fail()
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Logf("Reopen after Close opened the file again.")
+		t.Fail()
+	}
+	if f.signals != nil {
+		t.Logf("Close did not stop listening for signals.")
+		t.Fail()
+	}
+}
+
+func TestFileWithPrinter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, _ := NewFile(path, FileOptions{})
+	defer f.Close()
+
+	logger := lineprinter.New(10)
+	logger.OverrideTimeStamping(func() string { return "--static--" })
+	logger.OverrideTransport(f)
+	logger.Infof("test message")
+	<-logger.Flush()
+
+	if got := readFile(t, path); got != "--static-- INFO test message\n" {
+		t.Logf("Logger did not write to the file. Got: %q", got)
+		t.Fail()
+	}
+}
@@ -0,0 +1,380 @@
+package sinks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// HTTPFormat picks how a batch of messages is put into the request body.
+type HTTPFormat int
+
+const (
+	// NDJSON sends one message per line.
+	NDJSON HTTPFormat = iota
+	// JSONArray sends the messages as a JSON array. Only use it with the JSON printer.
+	JSONArray
+)
+
+const (
+	// DefaultHTTPMaxCount is the number of messages in a full batch.
+	DefaultHTTPMaxCount = 100
+	// DefaultHTTPMaxBytes is the size in bytes of a full batch.
+	DefaultHTTPMaxBytes = 1024 * 1024
+	// DefaultHTTPMaxLatency is how long a message can wait in a batch before it is sent.
+	DefaultHTTPMaxLatency = time.Second
+	// DefaultHTTPAttempts is the number of times a batch is posted before giving up.
+	DefaultHTTPAttempts = 3
+	// DefaultHTTPRetryDelay is how long to wait before the first retry.
+	DefaultHTTPRetryDelay = 100 * time.Millisecond
+	// DefaultHTTPMaxPending is the number of full batches that can wait to be posted.
+	DefaultHTTPMaxPending = 10
+)
+
+// HTTPOptions says where a HTTP sink posts messages and when.
+// Zero values are replaced with the defaults above.
+type HTTPOptions struct {
+	// URL is where batches are posted.
+	URL string
+	// Client is used to make requests, http.DefaultClient if nil.
+	Client *http.Client
+	// Header is added to every request, use it for authentication.
+	Header http.Header
+	// Format is how the batch is put into the body, NDJSON by default.
+	Format HTTPFormat
+	// Gzip compresses the body.
+	Gzip bool
+	// MaxCount sends the batch once it holds this many messages.
+	MaxCount int
+	// MaxBytes sends the batch once it holds this many bytes.
+	MaxBytes int
+	// MaxLatency sends the batch once its oldest message has waited this long.
+	MaxLatency time.Duration
+	// Attempts is how many times a batch is posted when the server fails with a 5xx status or
+	// can't be reached. The wait between attempts doubles each time.
+	Attempts int
+	// RetryDelay is the wait before the first retry.
+	RetryDelay time.Duration
+	// MaxPending is how many full batches can wait to be posted. Batches that fill up while
+	// this many are waiting are dropped.
+	MaxPending int
+}
+
+// HTTP is a Transport that collects messages into batches and posts them to a HTTP collector.
+// A batch is sent when it is full or its oldest message has waited long enough, and the partial
+// batch is sent when the printer is flushed.
+// Batches are posted one at a time, in order, by a background goroutine so that a slow collector
+// does not hold up the printer. Their failures are reported in Stats rather than to the printer.
+type HTTP struct {
+	// stats is kept first so that its counters are 64 bit aligned for atomic access.
+	stats      shared.Counters
+	options    HTTPOptions
+	lock       sync.Mutex
+	batch      [][]byte
+	batchBytes int
+	timer      *time.Timer
+	closed     bool
+	pending    chan httpBatch
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// httpBatch is a batch waiting to be posted. Flushes wait on done for the result.
+type httpBatch struct {
+	messages [][]byte
+	ctx      context.Context
+	done     chan error
+}
+
+// NewHTTP makes a HTTP sink ready for messages.
+func NewHTTP(options HTTPOptions) *HTTP {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.MaxCount <= 0 {
+		options.MaxCount = DefaultHTTPMaxCount
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = DefaultHTTPMaxBytes
+	}
+	if options.MaxLatency <= 0 {
+		options.MaxLatency = DefaultHTTPMaxLatency
+	}
+	if options.Attempts <= 0 {
+		options.Attempts = DefaultHTTPAttempts
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = DefaultHTTPRetryDelay
+	}
+	if options.MaxPending <= 0 {
+		options.MaxPending = DefaultHTTPMaxPending
+	}
+
+	h := &HTTP{
+		options: options,
+		pending: make(chan httpBatch, options.MaxPending),
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	go h.run()
+	return h
+}
+
+// Send adds msg to the batch. A full batch is handed to the background goroutine to be posted.
+// Messages sent after Close are dropped and os.ErrClosed is returned.
+func (h *HTTP) Send(_ context.Context, msg []byte) error {
+	msg = bytes.TrimRight(msg, "\n")
+	// The printer may reuse msg once Send returns.
+	stored := make([]byte, len(msg))
+	copy(stored, msg)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.closed {
+		h.stats.Dropped()
+		return os.ErrClosed
+	}
+
+	h.batch = append(h.batch, stored)
+	h.batchBytes += len(stored)
+	h.stats.Accepted()
+	if len(h.batch) >= h.options.MaxCount || h.batchBytes >= h.options.MaxBytes {
+		h.queue(h.take())
+		return nil
+	}
+	if h.timer == nil {
+		h.timer = time.AfterFunc(h.options.MaxLatency, h.sendLate)
+	}
+	return nil
+}
+
+// Flush posts the partial batch and waits for it and every batch before it to be posted.
+// The returned error is the result of posting the partial batch.
+func (h *HTTP) Flush(ctx context.Context) error {
+	h.lock.Lock()
+	if h.closed {
+		h.lock.Unlock()
+		return nil
+	}
+	batch := h.take()
+	h.lock.Unlock()
+
+	return h.wait(ctx, batch)
+}
+
+// Close posts the partial batch, waits for the batches before it and stops the background
+// goroutine. Messages sent afterwards are dropped.
+func (h *HTTP) Close() error {
+	h.lock.Lock()
+	if h.closed {
+		h.lock.Unlock()
+		return nil
+	}
+	h.closed = true
+	batch := h.take()
+	h.lock.Unlock()
+
+	err := h.wait(h.ctx, batch)
+	h.cancel()
+	return err
+}
+
+// Stats returns a snapshot of the sinks statistics. BufferDepth is the number of messages
+// waiting in the batch.
+func (h *HTTP) Stats() shared.Stats {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.stats.Snapshot(len(h.batch))
+}
+
+// sendLate is called by the timer once the oldest message has waited long enough.
+func (h *HTTP) sendLate() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if !h.closed {
+		h.queue(h.take())
+	}
+}
+
+// take empties the batch and returns what was in it. h.lock must be held.
+func (h *HTTP) take() [][]byte {
+	batch := h.batch
+	h.batch = nil
+	h.batchBytes = 0
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	return batch
+}
+
+// queue hands batch to the background goroutine. If MaxPending batches are already waiting
+// the batch is dropped so that the printer is never held up. h.lock must be held.
+func (h *HTTP) queue(batch [][]byte) {
+	if len(batch) == 0 {
+		return
+	}
+	select {
+	case h.pending <- httpBatch{messages: batch, ctx: h.ctx}:
+	default:
+		for range batch {
+			h.stats.Dropped()
+		}
+	}
+}
+
+// wait queues batch, even if it is empty, and waits for it to be posted.
+func (h *HTTP) wait(ctx context.Context, batch [][]byte) error {
+	done := make(chan error, 1)
+	select {
+	case h.pending <- httpBatch{messages: batch, ctx: ctx, done: done}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-h.ctx.Done():
+		return os.ErrClosed
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run posts the batches one at a time so that they arrive in order.
+func (h *HTTP) run() {
+	for {
+		select {
+		case batch := <-h.pending:
+			err := h.sendBatch(batch.ctx, batch.messages)
+			if batch.done != nil {
+				batch.done <- err
+			}
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// sendBatch posts batch. Every message in a batch that fails is counted as failed.
+func (h *HTTP) sendBatch(ctx context.Context, batch [][]byte) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	body, err := h.body(batch)
+	if err == nil {
+		err = h.post(ctx, body)
+	}
+	if err != nil {
+		for range batch {
+			h.stats.TransportFailed()
+		}
+		return err
+	}
+	h.stats.Written(len(body))
+	return nil
+}
+
+func (h *HTTP) body(batch [][]byte) ([]byte, error) {
+	b := &bytes.Buffer{}
+	var w io.Writer = b
+	var gz *gzip.Writer
+	if h.options.Gzip {
+		gz = gzip.NewWriter(b)
+		w = gz
+	}
+
+	if h.options.Format == JSONArray {
+		w.Write([]byte{'['})
+		w.Write(bytes.Join(batch, []byte{','}))
+		w.Write([]byte{']'})
+	} else {
+		for _, msg := range batch {
+			w.Write(msg)
+			w.Write([]byte{'\n'})
+		}
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// post sends body, trying again with a growing wait when the server can't be reached or
+// fails with a 5xx status.
+func (h *HTTP) post(ctx context.Context, body []byte) error {
+	delay := h.options.RetryDelay
+	var err error
+
+	for attempt := 0; attempt < h.options.Attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			delay *= 2
+		}
+
+		var retry bool
+		retry, err = h.postOnce(ctx, body)
+		if err == nil || !retry {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (h *HTTP) postOnce(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, h.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+
+	for key, values := range h.options.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if h.options.Format == JSONArray {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+	if h.options.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := h.options.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return true, fmt.Errorf("http sink: %s responded with %s", h.options.URL, resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("http sink: %s responded with %s", h.options.URL, resp.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,242 @@
+package sinks
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/jsonprinter"
+)
+
+// collector is a HTTP collector that keeps the bodies it is sent.
+type collector struct {
+	lock     sync.Mutex
+	bodies   []string
+	requests int
+	failures int
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.requests++
+	if c.failures > 0 {
+		c.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var body []byte
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, _ := gzip.NewReader(r.Body)
+		body, _ = ioutil.ReadAll(gz)
+	} else {
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	c.bodies = append(c.bodies, string(body))
+}
+
+func (c *collector) Bodies() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string{}, c.bodies...)
+}
+
+// WaitForBodies waits for count bodies to arrive, the full batches are posted in the background.
+func (c *collector) WaitForBodies(count int) []string {
+	deadline := time.Now().Add(time.Second)
+	for {
+		bodies := c.Bodies()
+		if len(bodies) >= count || time.Now().After(deadline) {
+			return bodies
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHTTPBatchesByCount(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	h := NewHTTP(HTTPOptions{URL: server.URL, MaxCount: 2, MaxLatency: time.Hour})
+	for _, msg := range []string{"1", "2", "3"} {
+		if err := h.Send(context.Background(), []byte(msg)); err != nil {
+			t.Logf("Send failed. Error: %s", err)
+			t.Fail()
+		}
+	}
+
+	if bodies := c.WaitForBodies(1); len(bodies) != 1 || bodies[0] != "1\n2\n" {
+		t.Logf("Full batch was not posted as NDJSON. Got: %q", bodies)
+		t.Fail()
+	}
+
+	h.Flush(context.Background())
+	if bodies := c.Bodies(); len(bodies) != 2 || bodies[1] != "3\n" {
+		t.Logf("Flush did not post the partial batch. Got: %q", bodies)
+		t.Fail()
+	}
+}
+
+func TestHTTPBatchesByBytes(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	h := NewHTTP(HTTPOptions{URL: server.URL, MaxBytes: 5, MaxLatency: time.Hour})
+	h.Send(context.Background(), []byte("abc"))
+	h.Send(context.Background(), []byte("def"))
+
+	if bodies := c.WaitForBodies(1); len(bodies) != 1 {
+		t.Logf("Batch was not posted once it was big enough. Got: %q", bodies)
+		t.Fail()
+	}
+}
+
+func TestHTTPBatchesByLatency(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	h := NewHTTP(HTTPOptions{URL: server.URL, MaxLatency: time.Millisecond * 10})
+	h.Send(context.Background(), []byte("late"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(c.Bodies()) == 0 {
+		if time.Now().After(deadline) {
+			t.Logf("Batch was not posted after the max latency.")
+			t.FailNow()
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHTTPRetries(t *testing.T) {
+	c := &collector{failures: 2}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	h := NewHTTP(HTTPOptions{URL: server.URL, MaxLatency: time.Hour, RetryDelay: time.Millisecond})
+	h.Send(context.Background(), []byte("retry me"))
+	if err := h.Flush(context.Background()); err != nil {
+		t.Logf("Flush gave up before running out of attempts. Error: %s", err)
+		t.Fail()
+	}
+	if c.requests != 3 || len(c.Bodies()) != 1 {
+		t.Logf("Wanted 3 requests and 1 body. Got %d requests and %q", c.requests, c.Bodies())
+		t.Fail()
+	}
+
+	c.lock.Lock()
+	c.failures = 5
+	c.lock.Unlock()
+	h.Send(context.Background(), []byte("lost"))
+	h.Send(context.Background(), []byte("also lost"))
+	if err := h.Flush(context.Background()); err == nil {
+		t.Logf("Flush did not fail after running out of attempts.")
+		t.Fail()
+	}
+	if stats := h.Stats(); stats.TransportFailures != 2 {
+		t.Logf("Every message in the failed batch should be counted. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestHTTPSlowCollector(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	h := NewHTTP(HTTPOptions{URL: server.URL, MaxCount: 1, MaxPending: 1, MaxLatency: time.Hour})
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := h.Send(context.Background(), []byte("slow")); err != nil {
+			t.Logf("Send failed. Error: %s", err)
+			t.Fail()
+		}
+	}
+	if took := time.Since(start); took > time.Second/2 {
+		t.Logf("Send waited for the collector. Took: %s", took)
+		t.Fail()
+	}
+	// One batch is being posted and one is waiting, the others have nowhere to go.
+	if stats := h.Stats(); stats.Accepted != 5 || stats.Dropped < 2 {
+		t.Logf("Batches that could not be queued were not dropped. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestHTTPSendAfterClose(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	h := NewHTTP(HTTPOptions{URL: server.URL, MaxLatency: time.Hour})
+	h.Send(context.Background(), []byte("before"))
+	if err := h.Close(); err != nil {
+		t.Logf("Close failed. Error: %s", err)
+		t.Fail()
+	}
+	if bodies := c.Bodies(); len(bodies) != 1 || bodies[0] != "before\n" {
+		t.Logf("Close did not post the partial batch. Got: %q", bodies)
+		t.Fail()
+	}
+
+	if err := h.Send(context.Background(), []byte("after")); err != os.ErrClosed {
+		t.Logf("Send after Close should return os.ErrClosed. Got: %v", err)
+		t.Fail()
+	}
+	if stats := h.Stats(); stats.Dropped != 1 || stats.BufferDepth != 0 {
+		t.Logf("Message sent after Close was not dropped. Stats: %+v", stats)
+		t.Fail()
+	}
+}
+
+func TestHTTPWithPrinter(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	h := NewHTTP(HTTPOptions{URL: server.URL, Format: JSONArray, Gzip: true, MaxLatency: time.Hour})
+	jp := jsonprinter.New(10)
+	jp.OverrideTransport(h)
+
+	for _, msg := range []string{"one", "two"} {
+		jm := jsonmessage.New()
+		jm.SetInfo()
+		jm.Message(msg)
+		jp.Send(jm)
+	}
+	// Flushing the printer posts the partial batch.
+	<-jp.Flush()
+
+	bodies := c.Bodies()
+	if len(bodies) != 1 {
+		t.Logf("Wanted 1 batch. Got: %q", bodies)
+		t.FailNow()
+	}
+
+	messages := []map[string]interface{}{}
+	if err := json.Unmarshal([]byte(bodies[0]), &messages); err != nil {
+		t.Logf("Batch is not a JSON array. Error: %s, Body: %s", err, bodies[0])
+		t.FailNow()
+	}
+	if len(messages) != 2 || !strings.Contains(bodies[0], `"log_message":"two"`) {
+		t.Logf("Batch has the wrong messages. Got: %s", bodies[0])
+		t.Fail()
+	}
+}
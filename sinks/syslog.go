@@ -0,0 +1,368 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/overrides"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// SyslogFormat picks the syslog message format.
+type SyslogFormat int
+
+const (
+	// RFC5424 is the modern syslog format. Fields of JSON messages are sent as structured data.
+	RFC5424 SyslogFormat = iota
+	// RFC3164 is the older BSD syslog format. Messages are sent as they are.
+	RFC3164
+)
+
+const (
+	// DefaultSyslogFacility is the user-level messages facility.
+	DefaultSyslogFacility = 1
+	// DefaultSyslogSDID is the structured data ID used for the fields of JSON messages.
+	DefaultSyslogSDID = "loggos@32473"
+	// DefaultSyslogDialTimeout is how long to wait for a connection to the syslog server.
+	DefaultSyslogDialTimeout = 5 * time.Second
+)
+
+// sdNameMax is the longest PARAM-NAME RFC 5424 allows.
+const sdNameMax = 32
+
+// syslogSeverities maps message levels onto syslog severities.
+var syslogSeverities = map[shared.Level]int{
+	shared.LevelTrace: 7,
+	shared.LevelDebug: 7,
+	shared.LevelInfo:  6,
+	shared.LevelWarn:  4,
+	shared.LevelError: 3,
+	shared.LevelCrit:  2,
+	shared.LevelFatal: 1,
+	shared.LevelPanic: 0,
+}
+
+// SyslogOptions says where a Syslog sends messages and how they are formatted.
+type SyslogOptions struct {
+	// Network is one of unix, unixgram, udp or tcp. unix tries a datagram socket first and
+	// then a stream socket.
+	Network string
+	// Address is the socket path or host:port of the syslog server.
+	Address string
+	// Format is the syslog message format, RFC5424 by default.
+	Format SyslogFormat
+	// Facility is the syslog facility, DefaultSyslogFacility if zero.
+	Facility int
+	// Hostname defaults to the name of this host.
+	Hostname string
+	// AppName defaults to the name of this program.
+	AppName string
+	// ProcID defaults to the pid of this process.
+	ProcID string
+	// MsgID is sent with RFC5424 messages, - if empty.
+	MsgID string
+	// SDID is the structured data ID for RFC5424 messages, DefaultSyslogSDID if empty.
+	SDID string
+	// DialTimeout is how long to wait for a connection, DefaultSyslogDialTimeout if zero.
+	DialTimeout time.Duration
+}
+
+// Syslog is a Transport that sends messages to a syslog server. The level of each message is
+// mapped onto a syslog severity. Over stream connections messages are framed with octet counting.
+// If a write fails the connection is dialled again and the write is tried once more.
+type Syslog struct {
+	lock    sync.Mutex
+	options SyslogOptions
+	conn    net.Conn
+	stream  bool
+	now     func() time.Time
+}
+
+// NewSyslog connects to the syslog server described by options.
+func NewSyslog(options SyslogOptions) (*Syslog, error) {
+	if options.Facility == 0 {
+		options.Facility = DefaultSyslogFacility
+	}
+	if options.Hostname == "" {
+		options.Hostname, _ = os.Hostname()
+	}
+	if options.AppName == "" {
+		options.AppName = filepath.Base(os.Args[0])
+	}
+	if options.ProcID == "" {
+		options.ProcID = strconv.Itoa(os.Getpid())
+	}
+	if options.SDID == "" {
+		options.SDID = DefaultSyslogSDID
+	}
+	if options.DialTimeout <= 0 {
+		options.DialTimeout = DefaultSyslogDialTimeout
+	}
+
+	s := &Syslog{
+		options: options,
+		now:     time.Now,
+	}
+	if err := s.dial(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Send formats msg as a syslog message and writes it to the server.
+func (s *Syslog) Send(ctx context.Context, msg []byte) error {
+	level, ok := overrides.LevelFromContext(ctx)
+	if !ok {
+		level = shared.LevelInfo
+	}
+
+	var packet []byte
+	if s.options.Format == RFC3164 {
+		packet = s.format3164(level, msg)
+	} else {
+		packet = s.format5424(level, msg)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.write(packet); err != nil {
+		if err := s.dial(ctx); err != nil {
+			return err
+		}
+		return s.write(packet)
+	}
+	return nil
+}
+
+// Close closes the connection to the server.
+func (s *Syslog) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// dial connects to the server, giving up after DialTimeout or when ctx is done so that Send is
+// not held up for long while the lock is held.
+func (s *Syslog) dial(ctx context.Context) error {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	networks := []string{s.options.Network}
+	if s.options.Network == "unix" {
+		networks = []string{"unixgram", "unix"}
+	}
+
+	dialer := net.Dialer{Timeout: s.options.DialTimeout}
+	var err error
+	for _, network := range networks {
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, network, s.options.Address)
+		if err == nil {
+			s.conn = conn
+			s.stream = network == "tcp" || network == "tcp4" || network == "tcp6" || network == "unix"
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *Syslog) write(packet []byte) error {
+	if s.conn == nil {
+		return os.ErrClosed
+	}
+
+	if s.stream {
+		framed := make([]byte, 0, len(packet)+8)
+		framed = strconv.AppendInt(framed, int64(len(packet)), 10)
+		framed = append(framed, ' ')
+		packet = append(framed, packet...)
+	}
+
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+func (s *Syslog) priority(level shared.Level) int {
+	severity, ok := syslogSeverities[level]
+	if !ok {
+		severity = syslogSeverities[shared.LevelInfo]
+	}
+	return s.options.Facility*8 + severity
+}
+
+// format3164 makes a BSD syslog message, <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+func (s *Syslog) format3164(level shared.Level, msg []byte) []byte {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "<%d>%s %s %s[%s]: ",
+		s.priority(level),
+		s.now().Format(time.Stamp),
+		s.options.Hostname,
+		s.options.AppName,
+		s.options.ProcID,
+	)
+	b.Write(bytes.TrimRight(msg, "\n"))
+	return b.Bytes()
+}
+
+// format5424 makes a RFC5424 syslog message,
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+// If msg is a JSON object its log message becomes MSG and the rest of its fields become
+// structured data, otherwise msg is sent as it is with no structured data.
+func (s *Syslog) format5424(level shared.Level, msg []byte) []byte {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "<%d>1 %s %s %s %s %s ",
+		s.priority(level),
+		s.now().Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(s.options.Hostname, 255),
+		headerField(s.options.AppName, 48),
+		headerField(s.options.ProcID, 128),
+		headerField(s.options.MsgID, 32),
+	)
+
+	fields, err := decodeObject(msg)
+	if err != nil {
+		b.WriteString("- ")
+		b.Write(bytes.TrimRight(msg, "\n"))
+		return b.Bytes()
+	}
+
+	logMessage, _ := fields[jsonmessage.JSONMessageKey].(string)
+	delete(fields, jsonmessage.JSONMessageKey)
+	writeStructuredData(b, s.options.SDID, fields)
+	if logMessage != "" {
+		b.WriteByte(' ')
+		b.WriteString(logMessage)
+	}
+	return b.Bytes()
+}
+
+// decodeObject decodes msg as a JSON object. Numbers are kept as json.Number so that large
+// integers are not rounded by going through float64.
+func decodeObject(msg []byte) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("syslog sink: message has data after the JSON object")
+	}
+	return fields, nil
+}
+
+// headerField makes a value safe for the RFC5424 header, which allows printable ASCII only.
+func headerField(value string, max int) string {
+	clean := make([]byte, 0, len(value))
+	for i := 0; i < len(value) && len(clean) < max; i++ {
+		if value[i] > 32 && value[i] < 127 {
+			clean = append(clean, value[i])
+		}
+	}
+	if len(clean) == 0 {
+		return "-"
+	}
+	return string(clean)
+}
+
+// writeStructuredData writes fields as a single SD-ELEMENT with the keys in order. Keys that
+// become the same PARAM-NAME once cleaned up get a numbered suffix so that no field is lost.
+func writeStructuredData(b *bytes.Buffer, id string, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		b.WriteByte('-')
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	used := make(map[string]bool, len(keys))
+	b.WriteByte('[')
+	b.WriteString(id)
+	for _, key := range keys {
+		name := sdName(key)
+		if name == "" {
+			continue
+		}
+		name = uniqueSDName(name, used)
+		used[name] = true
+		b.WriteByte(' ')
+		b.WriteString(name)
+		b.WriteString(`="`)
+		writeSDValue(b, fields[key])
+		b.WriteByte('"')
+	}
+	b.WriteByte(']')
+}
+
+// sdName makes a key safe to use as a PARAM-NAME, printable ASCII other than = ] " and space,
+// no longer than 32 characters.
+func sdName(key string) string {
+	clean := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(clean) < sdNameMax; i++ {
+		c := key[i]
+		if c > 32 && c < 127 && c != '=' && c != ']' && c != '"' {
+			clean = append(clean, c)
+		}
+	}
+	return string(clean)
+}
+
+// uniqueSDName returns name, or name with the first free _2, _3... suffix if it is already used.
+// name is cut short to make room for the suffix.
+func uniqueSDName(name string, used map[string]bool) string {
+	if !used[name] {
+		return name
+	}
+	for n := 2; ; n++ {
+		suffix := "_" + strconv.Itoa(n)
+		base := name
+		if len(base)+len(suffix) > sdNameMax {
+			base = base[:sdNameMax-len(suffix)]
+		}
+		if candidate := base + suffix; !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// writeSDValue writes a PARAM-VALUE, escaping " \ and ]. Values that are not strings are
+// written as JSON.
+func writeSDValue(b *bytes.Buffer, value interface{}) {
+	s, ok := value.(string)
+	if !ok {
+		encoded, _ := json.Marshal(value)
+		s = string(encoded)
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\\', ']':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+}
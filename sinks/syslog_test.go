@@ -0,0 +1,217 @@
+package sinks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/jsonprinter"
+	"github.com/silverstagtech/loggos/overrides"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+var syslogTestTime = time.Date(2019, time.March, 12, 10, 13, 31, 0, time.UTC)
+
+func newTestSyslog(t *testing.T, options SyslogOptions) *Syslog {
+	options.Hostname = "host"
+	options.AppName = "app"
+	options.ProcID = "42"
+
+	s, err := NewSyslog(options)
+	if err != nil {
+		t.Logf("Failed to connect to the test syslog listener. Error: %s", err)
+		t.FailNow()
+	}
+	s.now = func() time.Time { return syslogTestTime }
+	return s
+}
+
+func TestSyslogUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Logf("Failed to start UDP listener. Error: %s", err)
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	s := newTestSyslog(t, SyslogOptions{Network: "udp", Address: listener.LocalAddr().String()})
+	defer s.Close()
+
+	jm := jsonmessage.New()
+	jm.Add(jsonmessage.JSONTimeStampKey, "1")
+	jm.SetCrit()
+	jm.Message("disk full")
+	jm.Add("path", `/var/"data"]`)
+
+	ctx := overrides.ContextWithLevel(context.Background(), shared.LevelCrit)
+	if err := s.Send(ctx, jm.Bytes()); err != nil {
+		t.Logf("Failed to send to syslog. Error: %s", err)
+		t.FailNow()
+	}
+
+	buf := make([]byte, 2048)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Logf("Syslog listener did not get a message. Error: %s", err)
+		t.FailNow()
+	}
+
+	want := `<10>1 2019-03-12T10:13:31.000000Z host app 42 - [loggos@32473 level="CRIT" path="/var/\"data\"\]" timestamp="1"] disk full`
+	if got := string(buf[:n]); got != want {
+		t.Logf("Syslog message is wrong.\nGot:  %s\nWant: %s", got, want)
+		t.Fail()
+	}
+}
+
+func TestSyslogKeepsLargeIntegers(t *testing.T) {
+	s := &Syslog{
+		options: SyslogOptions{Facility: DefaultSyslogFacility, SDID: DefaultSyslogSDID},
+		now:     func() time.Time { return syslogTestTime },
+	}
+
+	jm := jsonmessage.New()
+	jm.Add(jsonmessage.JSONTimeStampKey, "1")
+	jm.Message("big numbers")
+	jm.Add("id", int64(9007199254740993))
+	jm.Add("nested", map[string]interface{}{"count": uint64(18446744073709551615)})
+	jm.Add("ratio", 0.5)
+
+	want := `<14>1 2019-03-12T10:13:31.000000Z - - - - [loggos@32473 id="9007199254740993" nested="{\"count\":18446744073709551615}" ratio="0.5" timestamp="1"] big numbers`
+	if got := string(s.format5424(shared.LevelInfo, jm.Bytes())); got != want {
+		t.Logf("Syslog message is wrong.\nGot:  %s\nWant: %s", got, want)
+		t.Fail()
+	}
+}
+
+func TestSyslogParamNamesDoNotCollide(t *testing.T) {
+	long := strings.Repeat("k", 32)
+	fields := map[string]interface{}{
+		"a=b":        "1",
+		"ab":         "2",
+		"ab_2":       "3",
+		long + "one": "4",
+		long + "two": "5",
+	}
+
+	b := &bytes.Buffer{}
+	writeStructuredData(b, DefaultSyslogSDID, fields)
+
+	want := `[loggos@32473 ab="1" ab_2="2" ab_2_2="3" ` + long + `="4" ` + strings.Repeat("k", 30) + `_2="5"]`
+	if got := b.String(); got != want {
+		t.Logf("PARAM-NAMEs collide or fields were lost.\nGot:  %s\nWant: %s", got, want)
+		t.Fail()
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Logf("Failed to start TCP listener. Error: %s", err)
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	s := newTestSyslog(t, SyslogOptions{Network: "tcp", Address: listener.Addr().String(), Format: RFC3164})
+	defer s.Close()
+
+	s.Send(overrides.ContextWithLevel(context.Background(), shared.LevelWarn), []byte("first line\n"))
+	s.Send(overrides.ContextWithLevel(context.Background(), shared.LevelDebug), []byte("second line"))
+
+	for _, want := range []string{
+		"<12>Mar 12 10:13:31 host app[42]: first line",
+		"<15>Mar 12 10:13:31 host app[42]: second line",
+	} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Logf("Syslog message is wrong.\nGot:  %s\nWant: %s", got, want)
+				t.Fail()
+			}
+		case <-time.After(time.Second):
+			t.Logf("Syslog listener did not get the octet counted message %q", want)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSyslogRedialHonoursContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Logf("Failed to start TCP listener. Error: %s", err)
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	s := newTestSyslog(t, SyslogOptions{Network: "tcp", Address: listener.Addr().String()})
+	s.Close()
+
+	// The write fails on the closed connection and the dial must give up with the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Send(ctx, []byte("lost")); err == nil {
+		t.Logf("Send dialled again with a cancelled context.")
+		t.Fail()
+	}
+}
+
+func TestSyslogWithPrinter(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Logf("Failed to start UDP listener. Error: %s", err)
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	s := newTestSyslog(t, SyslogOptions{Network: "udp", Address: listener.LocalAddr().String()})
+	defer s.Close()
+
+	jp := jsonprinter.New(10)
+	jp.OverrideTransport(s)
+	jm := jsonmessage.New()
+	jm.SetWarn()
+	jm.Message("test message")
+	jp.Send(jm)
+	<-jp.Flush()
+
+	buf := make([]byte, 2048)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Logf("Syslog listener did not get a message. Error: %s", err)
+		t.FailNow()
+	}
+	if got := string(buf[:n]); !strings.HasPrefix(got, "<12>1 ") || !strings.HasSuffix(got, "] test message") {
+		t.Logf("Printer did not send the message with its level. Got: %s", got)
+		t.Fail()
+	}
+}
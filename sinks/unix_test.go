@@ -0,0 +1,70 @@
+//go:build !windows
+// +build !windows
+
+package sinks
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFileReopenOnSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, _ := NewFile(path, FileOptions{})
+	f.ReopenOnSignal(syscall.SIGHUP)
+	defer f.Close()
+
+	f.Send(context.Background(), []byte("before"))
+	// Move the file out of the way like logrotate would.
+	os.Rename(path, path+".moved")
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Logf("File was not reopened after SIGHUP.")
+			t.FailNow()
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	f.Send(context.Background(), []byte("after"))
+	if got := readFile(t, path); got != "after\n" {
+		t.Logf("Reopened file has the wrong content. Got: %q", got)
+		t.Fail()
+	}
+}
+
+func TestSyslogUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	listener, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Logf("Failed to start unix socket listener. Error: %s", err)
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	s := newTestSyslog(t, SyslogOptions{Network: "unix", Address: path})
+	defer s.Close()
+	s.Send(context.Background(), []byte("plain message"))
+
+	buf := make([]byte, 2048)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Logf("Syslog listener did not get a message. Error: %s", err)
+		t.FailNow()
+	}
+	if got, want := string(buf[:n]), "<14>1 2019-03-12T10:13:31.000000Z host app 42 - - plain message"; got != want {
+		t.Logf("Syslog message is wrong.\nGot:  %s\nWant: %s", got, want)
+		t.Fail()
+	}
+}
@@ -0,0 +1,94 @@
+//go:build go1.21
+// +build go1.21
+
+// Package sloghandler lets code written against log/slog log through the loggos printers.
+// Records are turned into messages and sent like any other, so the decorations, mutators and
+// level of the printer apply to them too.
+package sloghandler
+
+import (
+	"log/slog"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// HandlerOptions changes how the handlers build messages. A nil *HandlerOptions uses the defaults.
+type HandlerOptions struct {
+	// AddSource writes where the record was logged from. Use it instead of EnableCaller on the
+	// printer, which would find the slog package rather than your code.
+	AddSource bool
+}
+
+// LevelFromSlog maps a slog level on to a loggos level. Levels between the slog levels round
+// down, so slog.LevelDebug-4 is shared.LevelTrace and slog.LevelInfo+2 is shared.LevelInfo.
+// Levels from slog.LevelError+4 are shared.LevelCrit. Records are never mapped to
+// shared.LevelFatal or shared.LevelPanic as the handlers don't exit or panic.
+func LevelFromSlog(level slog.Level) shared.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return shared.LevelTrace
+	case level < slog.LevelInfo:
+		return shared.LevelDebug
+	case level < slog.LevelWarn:
+		return shared.LevelInfo
+	case level < slog.LevelError:
+		return shared.LevelWarn
+	case level < slog.LevelError+4:
+		return shared.LevelError
+	}
+	return shared.LevelCrit
+}
+
+// groupedAttrs are attributes added with WithAttrs and the groups that were open at the time.
+type groupedAttrs struct {
+	groups []string
+	attrs  []slog.Attr
+}
+
+// state is what WithAttrs and WithGroup build up. It is copied by both, the slices are never
+// appended to in place so handlers can share them.
+type state struct {
+	opts   HandlerOptions
+	groups []string
+	attrs  []groupedAttrs
+}
+
+func newState(opts *HandlerOptions) state {
+	s := state{}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+func (s state) withAttrs(attrs []slog.Attr) state {
+	if len(attrs) == 0 {
+		return s
+	}
+	s.attrs = append(s.attrs[:len(s.attrs):len(s.attrs)], groupedAttrs{groups: s.groups, attrs: attrs})
+	return s
+}
+
+func (s state) withGroup(name string) state {
+	if name == "" {
+		return s
+	}
+	s.groups = append(s.groups[:len(s.groups):len(s.groups)], name)
+	return s
+}
+
+// source returns where the record was logged from and false if the record doesn't know.
+func (s state) source(r slog.Record) (shared.Caller, bool) {
+	if !s.opts.AddSource || r.PC == 0 {
+		return shared.Caller{}, false
+	}
+	return shared.CallerFromPC(r.PC), true
+}
+
+// ignored tells you if slog says the attribute should be left out.
+func ignored(a slog.Attr) bool {
+	if a.Equal(slog.Attr{}) {
+		return true
+	}
+	return a.Value.Kind() == slog.KindGroup && len(a.Value.Group()) == 0
+}
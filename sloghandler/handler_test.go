@@ -0,0 +1,162 @@
+//go:build go1.21
+// +build go1.21
+
+package sloghandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/silverstagtech/gotracer"
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/jsonprinter"
+	"github.com/silverstagtech/loggos/lineprinter"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+type upperMutator struct{}
+
+func (upperMutator) Mutate(msg *jsonmessage.JSONMessage) bool {
+	raw := msg.RawDump()
+	if user, ok := raw["user"].(string); ok {
+		raw["user"] = strings.ToUpper(user)
+	}
+	return true
+}
+
+func TestLevelFromSlog(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  shared.Level
+	}{
+		{level: slog.LevelDebug - 4, want: shared.LevelTrace},
+		{level: slog.LevelDebug, want: shared.LevelDebug},
+		{level: slog.LevelInfo, want: shared.LevelInfo},
+		{level: slog.LevelInfo + 2, want: shared.LevelInfo},
+		{level: slog.LevelWarn, want: shared.LevelWarn},
+		{level: slog.LevelError, want: shared.LevelError},
+		{level: slog.LevelError + 4, want: shared.LevelCrit},
+		{level: slog.LevelError + 100, want: shared.LevelCrit},
+	}
+	for _, test := range tests {
+		if got := LevelFromSlog(test.level); got != test.want {
+			t.Logf("%s mapped to %s, wanted %s.", test.level, got, test.want)
+			t.Fail()
+		}
+	}
+}
+
+func TestJSONHandler(t *testing.T) {
+	tracing := gotracer.New()
+	jp := jsonprinter.New(10)
+	jp.OverridePrinter(tracing)
+	jp.AddDecoration(map[string]interface{}{"service": "api"})
+	jp.AddMutator(upperMutator{})
+
+	logger := slog.New(NewJSONHandler(jp, nil))
+	logger.Debug("filtered")
+	logger.With("user", "alice").WithGroup("http").With("method", "GET").Info("request",
+		"status", 200,
+		slog.Group("timing", slog.Duration("took", 5)),
+		slog.Group("empty"),
+		"err", errors.New("boom"),
+	)
+	logger.Log(context.Background(), slog.LevelError+4, "critical")
+	<-jp.Flush()
+
+	got := tracing.Show()
+	if len(got) != 2 {
+		t.Fatalf("Wanted 2 messages, the debug record should be filtered. Got: %v", got)
+	}
+	for _, matcher := range []string{
+		`"http":\{"err":"boom","method":"GET","status":200,"timing":\{"took":5\}\}`,
+		`"level":"INFO"`,
+		`"log_message":"request"`,
+		`"service":"api"`,
+		`"user":"ALICE"`,
+	} {
+		if !regexp.MustCompile(matcher).MatchString(got[0]) {
+			t.Logf("Message does not match %s. Got: %s", matcher, got[0])
+			t.Fail()
+		}
+	}
+	if strings.Contains(got[0], "empty") {
+		t.Logf("Empty group was written. Got: %s", got[0])
+		t.Fail()
+	}
+	if !strings.Contains(got[1], `"level":"CRIT"`) {
+		t.Logf("Level was not mapped. Got: %s", got[1])
+		t.Fail()
+	}
+	// Enabled stops slog building records that the printer would throw away.
+	if NewJSONHandler(jp, nil).Enabled(context.Background(), slog.LevelDebug) {
+		t.Logf("Debug records are enabled above the level of the printer.")
+		t.Fail()
+	}
+}
+
+func TestJSONHandlerSource(t *testing.T) {
+	tracing := gotracer.New()
+	jp := jsonprinter.New(10)
+	jp.OverridePrinter(tracing)
+	// The caller found by the printer would be in log/slog, the one from the record must win.
+	jp.EnableCaller(true)
+
+	slog.New(NewJSONHandler(jp, &HandlerOptions{AddSource: true})).Info("source")
+	<-jp.Flush()
+
+	if got := tracing.Show(); len(got) != 1 || !strings.Contains(got[0], `"function":"sloghandler.TestJSONHandlerSource"`) {
+		t.Logf("Message does not have the caller from the record. Got: %v", got)
+		t.Fail()
+	}
+}
+
+func TestJSONHandlerRecordTime(t *testing.T) {
+	tracing := gotracer.New()
+	jp := jsonprinter.New(10)
+	jp.OverridePrinter(tracing)
+
+	at := time.Date(2019, 5, 2, 21, 4, 5, 6, time.UTC)
+	handler := NewJSONHandler(jp, nil)
+	handler.Handle(context.Background(), slog.NewRecord(at, slog.LevelInfo, "then", 0))
+	// Records without a time keep the time the message was made.
+	handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "now", 0))
+	<-jp.Flush()
+
+	got := tracing.Show()
+	if len(got) != 2 {
+		t.Logf("Wanted 2 messages. Got: %v", got)
+		t.FailNow()
+	}
+	stamp := `"timestamp":"` + strconv.FormatInt(at.UnixNano(), 10) + `"`
+	if !strings.Contains(got[0], stamp) {
+		t.Logf("Message was not stamped with the time of the record. Want %s. Got: %s", stamp, got[0])
+		t.Fail()
+	}
+	if strings.Contains(got[1], stamp) || !strings.Contains(got[1], `"timestamp":"`) {
+		t.Logf("Record without a time was not stamped with the time now. Got: %s", got[1])
+		t.Fail()
+	}
+}
+
+func TestLineHandler(t *testing.T) {
+	tracing := gotracer.New()
+	logger := lineprinter.New(10)
+	logger.OverridePrinter(tracing)
+
+	handler := NewLineHandler(logger.Named("slog"), &HandlerOptions{AddSource: true})
+	slog.New(handler).WithGroup("http").With("method", "GET").Warn("request", "status", 200)
+	<-logger.Flush()
+
+	matcher := `WARN slog: request http.method=GET http.status=200 caller=sloghandler/handler_test.go:\d+ function=sloghandler.TestLineHandler$`
+	if got := tracing.Show(); len(got) != 1 || !regexp.MustCompile(matcher).MatchString(got[0]) {
+		t.Logf("Line does not match %s. Got: %q", matcher, got)
+		t.Fail()
+	}
+}
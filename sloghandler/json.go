@@ -0,0 +1,137 @@
+//go:build go1.21
+// +build go1.21
+
+package sloghandler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/silverstagtech/loggos/jsonmessage"
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// JSONSender is where a JSONHandler sends messages, a *jsonprinter.JSONPrinter or a
+// *jsonprinter.Child. Messages are released back to the jsonmessage pool once SendContext
+// returns so it must not keep hold of them.
+type JSONSender interface {
+	Enabled(shared.Level) bool
+	SendContext(context.Context, *jsonmessage.JSONMessage)
+}
+
+// JSONHandler is a slog.Handler that turns records into JSON messages. Groups become nested
+// objects, see jsonmessage.Group. The timestamp is the time of the record, or the time the
+// message was made if the record has none.
+type JSONHandler struct {
+	sender JSONSender
+	state
+}
+
+// NewJSONHandler returns a handler that sends records to sender.
+func NewJSONHandler(sender JSONSender, opts *HandlerOptions) *JSONHandler {
+	return &JSONHandler{sender: sender, state: newState(opts)}
+}
+
+// Enabled tells slog if the printer would print a record at level.
+func (h *JSONHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.sender.Enabled(LevelFromSlog(level))
+}
+
+// Handle turns the record into a message and sends it with the context so that the context
+// fields of the printer are added too. The message is stamped with the time of the record.
+func (h *JSONHandler) Handle(ctx context.Context, r slog.Record) error {
+	msg := jsonmessage.Acquire()
+	defer msg.Release()
+	if !r.Time.IsZero() {
+		msg.SetTimeStamp(r.Time)
+	}
+	msg.SetLevel(LevelFromSlog(r.Level))
+	msg.Add(jsonmessage.JSONMessageKey, r.Message)
+	if caller, ok := h.source(r); ok {
+		msg.SetCaller(caller)
+	}
+
+	for _, grouped := range h.attrs {
+		addAttrs(msg, grouped.groups, grouped.attrs)
+	}
+	if r.NumAttrs() > 0 {
+		attrs := make([]slog.Attr, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		addAttrs(msg, h.groups, attrs)
+	}
+
+	h.sender.SendContext(ctx, msg)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *JSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &JSONHandler{sender: h.sender, state: h.withAttrs(attrs)}
+}
+
+// WithGroup returns a handler that puts the attributes of every record in a group called name.
+func (h *JSONHandler) WithGroup(name string) slog.Handler {
+	return &JSONHandler{sender: h.sender, state: h.withGroup(name)}
+}
+
+// target is what attributes are added to, a message or a group inside one.
+type target interface {
+	Add(string, interface{})
+	AddFields(...jsonmessage.Field)
+	Group(string) *jsonmessage.Group
+}
+
+// addAttrs adds attrs inside groups. The groups are only made if something is added to them.
+func addAttrs(t target, groups []string, attrs []slog.Attr) {
+	opened := false
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if ignored(a) {
+			continue
+		}
+		if !opened {
+			for _, group := range groups {
+				t = t.Group(group)
+			}
+			opened = true
+		}
+		addAttr(t, a)
+	}
+}
+
+// addAttr adds a resolved attribute, using typed fields where slog knows the type.
+func addAttr(t target, a slog.Attr) {
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		// A group without a key is written inline.
+		if a.Key == "" {
+			addAttrs(t, nil, a.Value.Group())
+			return
+		}
+		addAttrs(t, []string{a.Key}, a.Value.Group())
+	case slog.KindString:
+		t.AddFields(jsonmessage.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		t.AddFields(jsonmessage.Int64(a.Key, a.Value.Int64()))
+	case slog.KindFloat64:
+		t.AddFields(jsonmessage.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		t.AddFields(jsonmessage.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		t.AddFields(jsonmessage.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		t.AddFields(jsonmessage.Time(a.Key, a.Value.Time()))
+	case slog.KindUint64:
+		t.Add(a.Key, a.Value.Uint64())
+	default:
+		// Errors are written as their message like slog.JSONHandler does.
+		if err, ok := a.Value.Any().(error); ok {
+			t.Add(a.Key, err.Error())
+			return
+		}
+		t.Add(a.Key, a.Value.Any())
+	}
+}
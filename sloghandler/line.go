@@ -0,0 +1,98 @@
+//go:build go1.21
+// +build go1.21
+
+package sloghandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// LineSender is where a LineHandler sends messages, a *lineprinter.Logger or a
+// *lineprinter.Child.
+type LineSender interface {
+	Enabled(shared.Level) bool
+	Logf(shared.Level, string, ...interface{})
+}
+
+// LineHandler is a slog.Handler that turns records into lines. Attributes are written after the
+// message as key=value pairs, keys inside groups are joined to the group names with a dot.
+type LineHandler struct {
+	sender LineSender
+	state
+}
+
+// NewLineHandler returns a handler that sends records to sender.
+func NewLineHandler(sender LineSender, opts *HandlerOptions) *LineHandler {
+	return &LineHandler{sender: sender, state: newState(opts)}
+}
+
+// Enabled tells slog if the logger would print a record at level.
+func (h *LineHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.sender.Enabled(LevelFromSlog(level))
+}
+
+// Handle turns the record into a line and sends it.
+func (h *LineHandler) Handle(_ context.Context, r slog.Record) error {
+	b := strings.Builder{}
+	b.WriteString(r.Message)
+	for _, grouped := range h.attrs {
+		writeAttrs(&b, prefix(grouped.groups), grouped.attrs)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, prefix(h.groups), a)
+		return true
+	})
+	if caller, ok := h.source(r); ok {
+		b.WriteString(" caller=")
+		b.WriteString(caller.String())
+		b.WriteString(" function=")
+		b.WriteString(caller.Function)
+	}
+
+	h.sender.Logf(LevelFromSlog(r.Level), "%s", b.String())
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *LineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LineHandler{sender: h.sender, state: h.withAttrs(attrs)}
+}
+
+// WithGroup returns a handler that puts the attributes of every record in a group called name.
+func (h *LineHandler) WithGroup(name string) slog.Handler {
+	return &LineHandler{sender: h.sender, state: h.withGroup(name)}
+}
+
+// prefix joins groups into the start of a key.
+func prefix(groups []string) string {
+	if len(groups) == 0 {
+		return ""
+	}
+	return strings.Join(groups, ".") + "."
+}
+
+func writeAttrs(b *strings.Builder, prefix string, attrs []slog.Attr) {
+	for _, a := range attrs {
+		writeAttr(b, prefix, a)
+	}
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if ignored(a) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		writeAttrs(b, prefix, a.Value.Group())
+		return
+	}
+	fmt.Fprintf(b, " %s%s=%v", prefix, a.Key, a.Value.Any())
+}
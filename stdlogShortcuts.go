@@ -0,0 +1,42 @@
+package loggos
+
+import (
+	"log"
+	"os"
+
+	"github.com/silverstagtech/loggos/shared"
+)
+
+// The below functions send the output of the standard library log package to the default loggers.
+// Levels in square brackets at the start of a line, like [WARN], are used in place of the level
+// given. The log package flags and prefix are cleared as the loggers write their own timestamps.
+// The returned function puts the log package back the way it was, writing to stderr.
+
+// RedirectStdLog starts the default line logger if not already started then sends the output of
+// the log package to it at level.
+func RedirectStdLog(level shared.Level) func() {
+	startdefaultLineLogger()
+	return redirectStdLog(DefaultLineLogger.Writer(level))
+}
+
+// RedirectStdLogToJSON starts the default JSON logger if not already started then sends the
+// output of the log package to it at level.
+func RedirectStdLogToJSON(level shared.Level) func() {
+	startdefaultJSONLogger()
+	return redirectStdLog(DefaultJSONLogger.Writer(level))
+}
+
+func redirectStdLog(w *shared.LineWriter) func() {
+	flags, prefix := log.Flags(), log.Prefix()
+	w.DetectLevels(true)
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(w)
+
+	return func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		w.Flush()
+	}
+}
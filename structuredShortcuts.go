@@ -0,0 +1,48 @@
+package loggos
+
+import "github.com/silverstagtech/loggos/shared"
+
+// The below functions send structured messages to the default JSON logger in one call.
+// Look at the function comments for Log on the JSON Printer type for details on how keyvals are used.
+
+// Log sends msg and alternating keys and values at level to the default JSON logger.
+func Log(level shared.Level, msg string, keyvals ...interface{}) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.LogDepth(1, level, msg, keyvals...)
+}
+
+// Trace sends msg and alternating keys and values at LevelTrace to the default JSON logger.
+func Trace(msg string, keyvals ...interface{}) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.LogDepth(1, shared.LevelTrace, msg, keyvals...)
+}
+
+// Debug sends msg and alternating keys and values at LevelDebug to the default JSON logger.
+func Debug(msg string, keyvals ...interface{}) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.LogDepth(1, shared.LevelDebug, msg, keyvals...)
+}
+
+// Info sends msg and alternating keys and values at LevelInfo to the default JSON logger.
+func Info(msg string, keyvals ...interface{}) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.LogDepth(1, shared.LevelInfo, msg, keyvals...)
+}
+
+// Warn sends msg and alternating keys and values at LevelWarn to the default JSON logger.
+func Warn(msg string, keyvals ...interface{}) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.LogDepth(1, shared.LevelWarn, msg, keyvals...)
+}
+
+// Error sends msg and alternating keys and values at LevelError to the default JSON logger.
+func Error(msg string, keyvals ...interface{}) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.LogDepth(1, shared.LevelError, msg, keyvals...)
+}
+
+// Crit sends msg and alternating keys and values at LevelCrit to the default JSON logger.
+func Crit(msg string, keyvals ...interface{}) {
+	startdefaultJSONLogger()
+	DefaultJSONLogger.LogDepth(1, shared.LevelCrit, msg, keyvals...)
+}